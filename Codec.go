@@ -0,0 +1,118 @@
+package jsonapi
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// EncodeFunc converts the value into anything json.Marshal accepts. Primary keys have to be encoded as a string.
+type EncodeFunc func(v reflect.Value) (interface{}, error)
+
+// DecodeFunc fills in the value from its generic JSON representation as produced by json.Unmarshal into interface{},
+// numbers are passed as float64.
+type DecodeFunc func(data interface{}, v reflect.Value) error
+
+type codec struct {
+	encode EncodeFunc
+	decode DecodeFunc
+}
+
+var codecs sync.Map // map[reflect.Type]codec
+
+// RegisterCodec makes all following calls encode and decode values of the type with the provided functions.
+// It applies to attributes, nested attribute values, map values, slice elements and primary keys.
+// Either function can be nil to keep the default handling in that direction.
+func RegisterCodec(t reflect.Type, encode EncodeFunc, decode DecodeFunc) {
+	codecs.Store(t, codec{encode: encode, decode: decode})
+}
+
+// WithCodec is a per call equivalent of RegisterCodec, it takes precedence over registered codecs.
+func WithCodec(t reflect.Type, encode EncodeFunc, decode DecodeFunc) Option {
+	return func(o *options) {
+		if o.codecs == nil {
+			o.codecs = map[reflect.Type]codec{}
+		}
+		o.codecs[t] = codec{encode: encode, decode: decode}
+	}
+}
+
+func (o *options) codecFor(t reflect.Type) (codec, bool) {
+	if c, ok := o.codecs[t]; ok {
+		return c, true
+	}
+	if c, ok := codecs.Load(t); ok {
+		return c.(codec), true
+	}
+	return codec{}, false
+}
+
+func (o *options) hasCodec(t reflect.Type) bool {
+	if _, ok := o.codecFor(t); ok {
+		return true
+	}
+	if t.Kind() == reflect.Pointer {
+		_, ok := o.codecFor(t.Elem())
+		return ok
+	}
+	return false
+}
+
+// encodeWithCodec applies a codec registered either for the type of the value or for the type it points to.
+func encodeWithCodec(v reflect.Value, opts *options) (interface{}, bool, error) {
+	if c, ok := opts.codecFor(v.Type()); ok && c.encode != nil {
+		encoded, err := c.encode(v)
+		return encoded, true, err
+	}
+
+	if v.Kind() == reflect.Pointer {
+		if c, ok := opts.codecFor(v.Type().Elem()); ok && c.encode != nil {
+			if v.IsNil() {
+				return nil, true, nil
+			}
+			encoded, err := c.encode(v.Elem())
+			return encoded, true, err
+		}
+	}
+
+	return nil, false, nil
+}
+
+// decodeWithCodec is the counterpart of encodeWithCodec, the value has to be settable.
+func decodeWithCodec(v reflect.Value, data interface{}, opts *options) (bool, error) {
+	if c, ok := opts.codecFor(v.Type()); ok && c.decode != nil {
+		return true, c.decode(normalizeNumbers(data), v)
+	}
+
+	if v.Kind() == reflect.Pointer {
+		if c, ok := opts.codecFor(v.Type().Elem()); ok && c.decode != nil {
+			if data == nil {
+				v.Set(reflect.Zero(v.Type()))
+				return true, nil
+			}
+			target := reflect.New(v.Type().Elem())
+			if err := c.decode(normalizeNumbers(data), target.Elem()); err != nil {
+				return true, err
+			}
+			v.Set(target)
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func encodeIDWithCodec(v reflect.Value, opts *options) (string, bool, error) {
+	encoded, isHandled, err := encodeWithCodec(v, opts)
+	if !isHandled || err != nil {
+		return "", isHandled, err
+	}
+	if encoded == nil {
+		return "", true, nil
+	}
+	id, ok := encoded.(string)
+	if !ok {
+		return "", true, fmt.Errorf("codec for %s must encode primary key as a string, got %T", v.Type(), encoded)
+	}
+	return id, true, nil
+}
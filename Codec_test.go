@@ -0,0 +1,253 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// unregisterCodec removes a codec registered by a test so that other tests run with the default handling.
+func unregisterCodec(t reflect.Type) {
+	codecs.Delete(t)
+}
+
+func TestCodec(t *testing.T) {
+
+	type Cents struct {
+		value int64
+	}
+
+	encodeCents := func(v reflect.Value) (interface{}, error) {
+		c := v.Interface().(Cents)
+		return fmt.Sprintf("%d.%02d", c.value/100, c.value%100), nil
+	}
+	decodeCents := func(data interface{}, v reflect.Value) error {
+		s, ok := data.(string)
+		if !ok {
+			return fmt.Errorf("expected string, got %T", data)
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(Cents{value: int64(f*100 + 0.5)}))
+		return nil
+	}
+
+	RegisterCodec(reflect.TypeOf(Cents{}), encodeCents, decodeCents)
+	t.Cleanup(func() { unregisterCodec(reflect.TypeOf(Cents{})) })
+
+	type Nested struct {
+		Price Cents `json:"price"`
+	}
+
+	type SUT struct {
+		ID      string           `jsonapi:"primary,tests"`
+		Price   Cents            `jsonapi:"attr,price"`
+		PPrice  *Cents           `jsonapi:"attr,pPrice"`
+		NPrice  *Cents           `jsonapi:"attr,nPrice"`
+		Prices  []Cents          `jsonapi:"attr,prices"`
+		ByName  map[string]Cents `jsonapi:"attr,byName"`
+		Nested  Nested           `jsonapi:"attr,nested"`
+		Regular map[string]int   `jsonapi:"attr,regular"`
+	}
+
+	t.Run("should encode attributes with registered codec", func(t *testing.T) {
+		input := SUT{
+			ID:      "1",
+			Price:   Cents{value: 1234},
+			PPrice:  &Cents{value: 5},
+			Prices:  []Cents{{value: 100}, {value: 250}},
+			ByName:  map[string]Cents{"a": {value: 99}},
+			Nested:  Nested{Price: Cents{value: 1001}},
+			Regular: map[string]int{"a": 1},
+		}
+
+		raw, err := Marshal(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		check := map[string]interface{}{}
+		if err := json.Unmarshal(raw, &check); err != nil {
+			t.Fatal(err)
+		}
+		attrs := check["data"].(map[string]interface{})["attributes"].(map[string]interface{})
+
+		if attrs["price"] != "12.34" {
+			t.Fatal("unexpected top level value", attrs["price"])
+		}
+		if attrs["pPrice"] != "0.05" {
+			t.Fatal("unexpected pointer value", attrs["pPrice"])
+		}
+		if v, ok := attrs["nPrice"]; !ok || v != nil {
+			t.Fatal("expected nil pointer to be encoded as null")
+		}
+		if !reflect.DeepEqual(attrs["prices"], []interface{}{"1.00", "2.50"}) {
+			t.Fatal("unexpected slice value", attrs["prices"])
+		}
+		if !reflect.DeepEqual(attrs["byName"], map[string]interface{}{"a": "0.99"}) {
+			t.Fatal("unexpected map value", attrs["byName"])
+		}
+		if !reflect.DeepEqual(attrs["nested"], map[string]interface{}{"price": "10.01"}) {
+			t.Fatal("unexpected nested value", attrs["nested"])
+		}
+		if !reflect.DeepEqual(attrs["regular"], map[string]interface{}{"a": float64(1)}) {
+			t.Fatal("unexpected regular map value", attrs["regular"])
+		}
+	})
+
+	t.Run("should decode attributes with registered codec", func(t *testing.T) {
+		raw := []byte(`{"data":{"id":"1","type":"tests","attributes":{
+			"price":"12.34","pPrice":"0.05","nPrice":null,"prices":["1.00","2.50"],
+			"byName":{"a":"0.99"},"nested":{"price":"10.01"}}}}`)
+
+		out := SUT{}
+		if err := Unmarshal(raw, &out); err != nil {
+			t.Fatal(err)
+		}
+
+		if out.Price.value != 1234 {
+			t.Fatal("unexpected top level value", out.Price)
+		}
+		if out.PPrice == nil || out.PPrice.value != 5 {
+			t.Fatal("unexpected pointer value", out.PPrice)
+		}
+		if out.NPrice != nil {
+			t.Fatal("expected nil pointer")
+		}
+		if !reflect.DeepEqual(out.Prices, []Cents{{value: 100}, {value: 250}}) {
+			t.Fatal("unexpected slice value", out.Prices)
+		}
+		if !reflect.DeepEqual(out.ByName, map[string]Cents{"a": {value: 99}}) {
+			t.Fatal("unexpected map value", out.ByName)
+		}
+		if out.Nested.Price.value != 1001 {
+			t.Fatal("unexpected nested value", out.Nested)
+		}
+	})
+
+	t.Run("should return decode errors", func(t *testing.T) {
+		raw := []byte(`{"data":{"id":"1","type":"tests","attributes":{"price":12}}}`)
+
+		out := SUT{}
+		if err := Unmarshal(raw, &out); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+
+	t.Run("should prefer per call codec and return encode errors", func(t *testing.T) {
+		failure := errors.New("cannot encode")
+		input := SUT{ID: "1", Price: Cents{value: 1}}
+
+		_, err := Marshal(input, WithCodec(reflect.TypeOf(Cents{}), func(v reflect.Value) (interface{}, error) {
+			return nil, failure
+		}, nil))
+		if !errors.Is(err, failure) {
+			t.Fatal("expected codec error, got", err)
+		}
+
+		raw, err := Marshal(input, WithCodec(reflect.TypeOf(Cents{}), func(v reflect.Value) (interface{}, error) {
+			return v.Interface().(Cents).value, nil
+		}, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		check := map[string]interface{}{}
+		if err := json.Unmarshal(raw, &check); err != nil {
+			t.Fatal(err)
+		}
+		if check["data"].(map[string]interface{})["attributes"].(map[string]interface{})["price"] != float64(1) {
+			t.Fatal("expected per call codec to be used")
+		}
+	})
+
+	t.Run("should encode and decode primary key with codec", func(t *testing.T) {
+		type Key struct {
+			a, b string
+		}
+		type WithKey struct {
+			ID   Key    `jsonapi:"primary,tests"`
+			Name string `jsonapi:"attr,name"`
+		}
+
+		keyCodec := WithCodec(reflect.TypeOf(Key{}), func(v reflect.Value) (interface{}, error) {
+			k := v.Interface().(Key)
+			return k.a + ":" + k.b, nil
+		}, func(data interface{}, v reflect.Value) error {
+			s, _ := data.(string)
+			for i := range s {
+				if s[i] == ':' {
+					v.Set(reflect.ValueOf(Key{a: s[:i], b: s[i+1:]}))
+					return nil
+				}
+			}
+			return fmt.Errorf("malformed key %s", s)
+		})
+
+		raw, err := Marshal(WithKey{ID: Key{a: "x", b: "y"}, Name: "n"}, keyCodec)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		check := map[string]interface{}{}
+		if err := json.Unmarshal(raw, &check); err != nil {
+			t.Fatal(err)
+		}
+		if check["data"].(map[string]interface{})["id"] != "x:y" {
+			t.Fatal("unexpected id", check["data"])
+		}
+
+		out := WithKey{}
+		if err := Unmarshal(raw, &out, keyCodec); err != nil {
+			t.Fatal(err)
+		}
+		if out.ID != (Key{a: "x", b: "y"}) || out.Name != "n" {
+			t.Fatal("unexpected round trip result", out)
+		}
+	})
+
+	t.Run("should pass numbers to decoders as float64", func(t *testing.T) {
+		type Ratio struct {
+			value float64
+		}
+		type WithRatio struct {
+			ID    string `jsonapi:"primary,tests"`
+			Ratio Ratio  `jsonapi:"attr,ratio"`
+		}
+
+		ratioCodec := WithCodec(reflect.TypeOf(Ratio{}), nil, func(data interface{}, v reflect.Value) error {
+			f, ok := data.(float64)
+			if !ok {
+				return fmt.Errorf("expected float64, got %T", data)
+			}
+			v.Set(reflect.ValueOf(Ratio{value: f}))
+			return nil
+		})
+
+		out := WithRatio{}
+		if err := Unmarshal([]byte(`{"data":{"type":"tests","id":"1","attributes":{"ratio":1.5}}}`), &out, ratioCodec); err != nil {
+			t.Fatal(err)
+		}
+		if out.Ratio.value != 1.5 {
+			t.Fatal("unexpected ratio", out.Ratio)
+		}
+	})
+
+	t.Run("should reject primary key codec not producing a string", func(t *testing.T) {
+		type WithKey struct {
+			ID Cents `jsonapi:"primary,tests"`
+		}
+
+		_, err := Marshal(WithKey{ID: Cents{value: 1}}, WithCodec(reflect.TypeOf(Cents{}), func(v reflect.Value) (interface{}, error) {
+			return 1, nil
+		}, nil))
+		if err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
@@ -0,0 +1,291 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sort"
+)
+
+// Links is a links object, values are either strings or link objects with href and meta.
+type Links map[string]interface{}
+
+// Meta is a meta object with non-standard meta-information.
+type Meta map[string]interface{}
+
+// Document is a schema-less representation of a top-level JSON:API document.
+// Numbers in attributes and meta are kept as json.Number so that rewriting a document doesn't alter them.
+type Document struct {
+	//Data holds primary data, a single resource unless Many is set. Empty Data without Many stands for null primary data
+	Data []*Resource
+	Many bool
+	//Errors documents are encoded without data
+	Errors   []*JSONAPIError
+	Included []*Resource
+	Links    Links
+	Meta     Meta
+	JSONAPI  map[string]interface{}
+}
+
+type Resource struct {
+	Type          string                   `json:"type"`
+	ID            string                   `json:"id,omitempty"`
+	LID           string                   `json:"lid,omitempty"`
+	Attributes    map[string]interface{}   `json:"attributes,omitempty"`
+	Relationships map[string]*Relationship `json:"relationships,omitempty"`
+	Links         Links                    `json:"links,omitempty"`
+	Meta          Meta                     `json:"meta,omitempty"`
+}
+
+type ResourceIdentifier struct {
+	Type string `json:"type"`
+	ID   string `json:"id,omitempty"`
+	LID  string `json:"lid,omitempty"`
+	Meta Meta   `json:"meta,omitempty"`
+}
+
+// Relationship is a relationship object. Data holds a single identifier for to-one relationships.
+// State is set on decoding. On encoding data is emitted for RelationNull and RelationPresent states, and whenever
+// Data is not empty or ToMany is set. Relationships with neither data, links nor meta are left out of resources.
+type Relationship struct {
+	Data   []ResourceIdentifier
+	ToMany bool
+	State  RelationState
+	Links  Links
+	Meta   Meta
+}
+
+type documentJSON struct {
+	Data     json.RawMessage        `json:"data,omitempty"`
+	Errors   []*JSONAPIError        `json:"errors,omitempty"`
+	Included []*Resource            `json:"included,omitempty"`
+	Links    Links                  `json:"links,omitempty"`
+	Meta     Meta                   `json:"meta,omitempty"`
+	JSONAPI  map[string]interface{} `json:"jsonapi,omitempty"`
+}
+
+type relationshipJSON struct {
+	Data  json.RawMessage `json:"data,omitempty"`
+	Links Links           `json:"links,omitempty"`
+	Meta  Meta            `json:"meta,omitempty"`
+}
+
+var jsonNull = []byte("null")
+
+func (d Document) MarshalJSON() ([]byte, error) {
+	raw := documentJSON{
+		Errors:   d.Errors,
+		Included: d.Included,
+		Links:    d.Links,
+		Meta:     d.Meta,
+		JSONAPI:  d.JSONAPI,
+	}
+
+	if len(d.Errors) == 0 {
+		var err error
+		switch {
+		case d.Many:
+			data := d.Data
+			if data == nil {
+				data = []*Resource{}
+			}
+			raw.Data, err = json.Marshal(data)
+		case len(d.Data) > 0:
+			raw.Data, err = json.Marshal(d.Data[0])
+		default:
+			raw.Data = jsonNull
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(raw)
+}
+
+func (d *Document) UnmarshalJSON(data []byte) error {
+	raw := documentJSON{}
+	if err := decodeJSON(data, &raw); err != nil {
+		return err
+	}
+
+	*d = Document{
+		Errors:   raw.Errors,
+		Included: raw.Included,
+		Links:    raw.Links,
+		Meta:     raw.Meta,
+		JSONAPI:  raw.JSONAPI,
+	}
+
+	primary := bytes.TrimSpace(raw.Data)
+	switch {
+	case len(primary) == 0, bytes.Equal(primary, jsonNull):
+		return nil
+	case primary[0] == '[':
+		d.Many = true
+		d.Data = make([]*Resource, 0)
+		return decodeJSON(primary, &d.Data)
+	case primary[0] == '{':
+		resource := &Resource{}
+		if err := decodeJSON(primary, resource); err != nil {
+			return err
+		}
+		d.Data = []*Resource{resource}
+		return nil
+	default:
+		return errors.New("invalid data structure")
+	}
+}
+
+func (r Resource) MarshalJSON() ([]byte, error) {
+	type resourceJSON Resource
+	out := resourceJSON(r)
+
+	if len(r.Relationships) > 0 {
+		out.Relationships = make(map[string]*Relationship, len(r.Relationships))
+		for name, relationship := range r.Relationships {
+			if !relationship.isEmpty() {
+				out.Relationships[name] = relationship
+			}
+		}
+	}
+
+	return json.Marshal(out)
+}
+
+// isEmpty reports whether the relationship would encode without data, links and meta, which is not a valid relationship object.
+func (r *Relationship) isEmpty() bool {
+	return r == nil || (r.State == RelationAbsent && !r.ToMany && len(r.Data) == 0 && len(r.Links) == 0 && len(r.Meta) == 0)
+}
+
+func (r Relationship) MarshalJSON() ([]byte, error) {
+	raw := relationshipJSON{
+		Links: r.Links,
+		Meta:  r.Meta,
+	}
+
+	var err error
+	switch {
+	case r.ToMany:
+		data := r.Data
+		if data == nil {
+			data = []ResourceIdentifier{}
+		}
+		raw.Data, err = json.Marshal(data)
+	case len(r.Data) > 0:
+		raw.Data, err = json.Marshal(r.Data[0])
+	case r.State != RelationAbsent:
+		raw.Data = jsonNull
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(raw)
+}
+
+func (r *Relationship) UnmarshalJSON(data []byte) error {
+	raw := relationshipJSON{}
+	if err := decodeJSON(data, &raw); err != nil {
+		return err
+	}
+
+	*r = Relationship{
+		Links: raw.Links,
+		Meta:  raw.Meta,
+	}
+
+	linkage := bytes.TrimSpace(raw.Data)
+	switch {
+	case len(linkage) == 0:
+		r.State = RelationAbsent
+		return nil
+	case bytes.Equal(linkage, jsonNull):
+		r.State = RelationNull
+		return nil
+	case linkage[0] == '[':
+		r.State = RelationPresent
+		r.ToMany = true
+		r.Data = make([]ResourceIdentifier, 0)
+		return decodeJSON(linkage, &r.Data)
+	case linkage[0] == '{':
+		r.State = RelationPresent
+		identifier := ResourceIdentifier{}
+		if err := decodeJSON(linkage, &identifier); err != nil {
+			return err
+		}
+		r.Data = []ResourceIdentifier{identifier}
+		return nil
+	default:
+		return errors.New("invalid relationship data structure")
+	}
+}
+
+func (r *Resource) Identifier() ResourceIdentifier {
+	return ResourceIdentifier{Type: r.Type, ID: r.ID, LID: r.LID}
+}
+
+// FindIncluded returns the included resource with the type and id, or nil if the document doesn't include it.
+func (d *Document) FindIncluded(resourceType string, id string) *Resource {
+	for _, resource := range d.Included {
+		if resource.Type == resourceType && resource.ID == id {
+			return resource
+		}
+	}
+	return nil
+}
+
+// Related resolves relationship linkage against included resources. Identifiers without an included resource are skipped.
+func (d *Document) Related(relationship *Relationship) []*Resource {
+	out := make([]*Resource, 0, len(relationship.Data))
+	for _, identifier := range relationship.Data {
+		if resource := d.FindIncluded(identifier.Type, identifier.ID); resource != nil {
+			out = append(out, resource)
+		}
+	}
+	return out
+}
+
+// WalkRelationships calls fn for every relationship of primary and included resources, in document order and
+// by relationship name within a resource. Walking stops at the first error, which is returned.
+func (d *Document) WalkRelationships(fn func(resource *Resource, name string, relationship *Relationship) error) error {
+	for _, resources := range [][]*Resource{d.Data, d.Included} {
+		for _, resource := range resources {
+			names := make([]string, 0, len(resource.Relationships))
+			for name := range resource.Relationships {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			for _, name := range names {
+				if err := fn(resource, name, resource.Relationships[name]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// MarshalDocument converts tagged structs into a Document, accepting the same input and options as Marshal.
+func MarshalDocument(in interface{}, opts ...Option) (*Document, error) {
+	raw, err := Marshal(in, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &Document{}
+	if err := json.Unmarshal(raw, doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// UnmarshalDocument fills in tagged structs from a Document, accepting the same model and options as Unmarshal.
+func UnmarshalDocument(doc *Document, model interface{}, opts ...Option) error {
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return Unmarshal(raw, model, opts...)
+}
@@ -0,0 +1,211 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDocument(t *testing.T) {
+
+	type Person struct {
+		ID   string `jsonapi:"primary,people"`
+		Name string `jsonapi:"attr,name"`
+	}
+
+	type Article struct {
+		ID       string    `jsonapi:"primary,articles"`
+		Title    string    `jsonapi:"attr,title"`
+		Views    int64     `jsonapi:"attr,views"`
+		Author   *Person   `jsonapi:"relation,author"`
+		Editor   *Person   `jsonapi:"relation,editor"`
+		Comments []*Person `jsonapi:"relation,comments"`
+	}
+
+	t.Run("should decode and encode a document without loss", func(t *testing.T) {
+		raw := []byte(`{
+			"data":{"type":"articles","id":"1","attributes":{"title":"t","views":9007199254740993},
+				"relationships":{
+					"author":{"data":{"type":"people","id":"2"}},
+					"editor":{"data":null},
+					"comments":{"data":[]},
+					"tags":{"links":{"related":"/articles/1/tags"}}
+				}},
+			"included":[{"type":"people","id":"2","attributes":{"name":"a"}}],
+			"meta":{"total":1}
+		}`)
+
+		doc := &Document{}
+		if err := json.Unmarshal(raw, doc); err != nil {
+			t.Fatal(err)
+		}
+
+		if doc.Many || len(doc.Data) != 1 {
+			t.Fatal("expected single primary resource", doc.Data)
+		}
+		article := doc.Data[0]
+		if article.Attributes["views"] != json.Number("9007199254740993") {
+			t.Fatal("expected number to be kept", article.Attributes["views"])
+		}
+
+		author := article.Relationships["author"]
+		if author.State != RelationPresent || author.ToMany || !reflect.DeepEqual(author.Data, []ResourceIdentifier{{Type: "people", ID: "2"}}) {
+			t.Fatal("unexpected author relationship", author)
+		}
+		if article.Relationships["editor"].State != RelationNull {
+			t.Fatal("unexpected editor relationship", article.Relationships["editor"])
+		}
+		comments := article.Relationships["comments"]
+		if comments.State != RelationPresent || !comments.ToMany || len(comments.Data) != 0 {
+			t.Fatal("unexpected comments relationship", comments)
+		}
+		tags := article.Relationships["tags"]
+		if tags.State != RelationAbsent || tags.Links["related"] != "/articles/1/tags" {
+			t.Fatal("unexpected tags relationship", tags)
+		}
+
+		encoded, err := json.Marshal(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var expected, actual interface{}
+		if err := json.Unmarshal(raw, &expected); err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(encoded, &actual); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(expected, actual) {
+			t.Errorf("expected %s, got %s", raw, encoded)
+		}
+	})
+
+	t.Run("should encode null and collection primary data and errors", func(t *testing.T) {
+		cases := map[string]Document{
+			`{"data":null}`: {},
+			`{"data":[]}`:   {Many: true},
+			`{"errors":[{"id":"","status":"404","title":"Not found","detail":""}]}`: {Errors: []*JSONAPIError{{Status: "404", Title: "Not found"}}},
+		}
+
+		for expected, doc := range cases {
+			raw, err := json.Marshal(doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(raw) != expected {
+				t.Errorf("expected %s, got %s", expected, raw)
+			}
+
+			decoded := Document{}
+			if err := json.Unmarshal(raw, &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if decoded.Many != doc.Many || len(decoded.Data) != 0 || len(decoded.Errors) != len(doc.Errors) {
+				t.Errorf("unexpected decoded document %+v", decoded)
+			}
+		}
+	})
+
+	t.Run("should leave out relationships without data, links and meta", func(t *testing.T) {
+		doc := &Document{Data: []*Resource{{
+			Type: "articles",
+			ID:   "1",
+			Relationships: map[string]*Relationship{
+				"author": {},
+				"editor": {State: RelationNull},
+			},
+		}}}
+
+		raw, err := json.Marshal(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(raw) != `{"data":{"type":"articles","id":"1","relationships":{"editor":{"data":null}}}}` {
+			t.Fatal("unexpected document", string(raw))
+		}
+
+		decoded := &Document{}
+		if err := json.Unmarshal(raw, decoded); err != nil {
+			t.Fatal(err)
+		}
+		relationships := decoded.Data[0].Relationships
+		if _, ok := relationships["author"]; ok || relationships["editor"].State != RelationNull {
+			t.Fatal("unexpected relationships", relationships)
+		}
+
+		doc.Data[0].Relationships = map[string]*Relationship{"author": {}}
+		if raw, err = json.Marshal(doc); err != nil {
+			t.Fatal(err)
+		}
+		if string(raw) != `{"data":{"type":"articles","id":"1"}}` {
+			t.Fatal("unexpected document", string(raw))
+		}
+	})
+
+	t.Run("should find included resources and walk relationships", func(t *testing.T) {
+		doc, err := MarshalDocument(&Article{
+			ID:       "1",
+			Author:   &Person{ID: "2", Name: "a"},
+			Comments: []*Person{{ID: "3", Name: "c"}, {ID: "2"}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if person := doc.FindIncluded("people", "2"); person == nil || person.Attributes["name"] != "a" {
+			t.Fatal("expected included person", person)
+		}
+		if doc.FindIncluded("people", "4") != nil {
+			t.Fatal("expected missing resource to be nil")
+		}
+
+		related := doc.Related(doc.Data[0].Relationships["comments"])
+		if len(related) != 2 || related[0].ID != "3" || related[1].ID != "2" {
+			t.Fatal("unexpected related resources", related)
+		}
+
+		visited := make([]string, 0)
+		err = doc.WalkRelationships(func(resource *Resource, name string, relationship *Relationship) error {
+			visited = append(visited, resource.Type+"/"+resource.ID+"/"+name)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(visited, []string{"articles/1/author", "articles/1/comments", "articles/1/editor"}) {
+			t.Fatal("unexpected walk order", visited)
+		}
+
+		stop := errors.New("stop")
+		if err := doc.WalkRelationships(func(*Resource, string, *Relationship) error { return stop }); !errors.Is(err, stop) {
+			t.Fatal("expected walk error to be returned", err)
+		}
+	})
+
+	t.Run("should convert rewritten documents into models", func(t *testing.T) {
+		doc, err := MarshalDocument([]*Article{{ID: "1", Title: "t", Views: 9007199254740993}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !doc.Many {
+			t.Fatal("expected collection document")
+		}
+
+		doc.Data[0].Attributes["title"] = "rewritten"
+		doc.Data[0].Relationships["author"] = &Relationship{Data: []ResourceIdentifier{{Type: "people", ID: "5"}}}
+
+		out := make([]*Article, 0)
+		if err := UnmarshalDocument(doc, &out); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(out) != 1 || out[0].Title != "rewritten" || out[0].Views != 9007199254740993 {
+			t.Fatal("unexpected models", out)
+		}
+		if out[0].Author == nil || out[0].Author.ID != "5" {
+			t.Fatal("unexpected author", out[0].Author)
+		}
+	})
+}
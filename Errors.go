@@ -3,6 +3,7 @@ package jsonapi
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 )
 
 type ErrorsPayload struct {
@@ -44,3 +45,22 @@ func (e *JSONAPIError) Error() string {
 func MarshalErrors(errs []*JSONAPIError) ([]byte, error) {
 	return json.Marshal(ErrorsPayload{errs})
 }
+
+func (p *ErrorsPayload) Error() string {
+	messages := make([]string, len(p.Errors))
+	for i, err := range p.Errors {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "")
+}
+
+func newParameterError(parameter string, title string, detail string) *JSONAPIError {
+	return &JSONAPIError{
+		Status: "400",
+		Title:  title,
+		Detail: detail,
+		Source: map[string]interface{}{
+			"parameter": parameter,
+		},
+	}
+}
@@ -0,0 +1,176 @@
+package jsonapi
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+var fieldsCache sync.Map // map[reflect.Type][]reflect.StructField
+
+// resourceFields lists the fields of a struct type with fields of anonymous structs promoted to the top level following
+// encoding/json rules. Index of every returned field is the full path from the provided type.
+// Among fields sharing the same encoded name the shallowest wins, on equal depth a tagged field wins over untagged,
+// and remaining conflicts between promoted fields drop all contenders. Primary keys compete with each other the same way.
+func resourceFields(t reflect.Type) []reflect.StructField {
+	if cached, ok := fieldsCache.Load(t); ok {
+		return cached.([]reflect.StructField)
+	}
+
+	type level struct {
+		t     reflect.Type
+		index []int
+	}
+
+	candidates := make([]reflect.StructField, 0)
+	visited := map[reflect.Type]bool{}
+	current := []level{{t: t}}
+
+	for len(current) > 0 {
+		next := make([]level, 0)
+		for _, l := range current {
+			//Types seen on shallower levels are skipped to break embedding loops, while the same type embedded twice
+			//on one level is kept so that its fields annihilate each other
+			if visited[l.t] {
+				continue
+			}
+
+			for i, n := 0, l.t.NumField(); i < n; i++ {
+				field := l.t.Field(i)
+				field.Index = append(slices.Clone(l.index), i)
+
+				if field.Anonymous && isPromoted(field) {
+					embedded := field.Type
+					if embedded.Kind() == reflect.Pointer {
+						embedded = embedded.Elem()
+					}
+					next = append(next, level{t: embedded, index: field.Index})
+					continue
+				}
+
+				if !field.IsExported() && field.Tag.Get("jsonapi") == "" && field.Tag.Get("json") == "" {
+					continue
+				}
+
+				candidates = append(candidates, field)
+			}
+		}
+		for _, l := range current {
+			visited[l.t] = true
+		}
+		current = next
+	}
+
+	out := make([]reflect.StructField, 0, len(candidates))
+	for _, field := range candidates {
+		if isDominantField(field, candidates) {
+			out = append(out, field)
+		}
+	}
+
+	slices.SortStableFunc(out, func(a, b reflect.StructField) int {
+		return slices.Compare(a.Index, b.Index)
+	})
+
+	fieldsCache.Store(t, out)
+	return out
+}
+
+// isPromoted reports whether fields of the anonymous struct field should be promoted to the parent resource.
+// Embedded structs explicitly named through a tag are treated as regular fields.
+func isPromoted(field reflect.StructField) bool {
+	if field.Tag.Get("jsonapi") != "" {
+		return false
+	}
+	jsonTag := field.Tag.Get("json")
+	if jsonTag == "-" || strings.Split(jsonTag, ",")[0] != "" {
+		return false
+	}
+
+	embedded := field.Type
+	if embedded.Kind() == reflect.Pointer {
+		if !field.IsExported() {
+			//Unexported embedded pointers cannot be allocated on unmarshal
+			return false
+		}
+		embedded = embedded.Elem()
+	}
+	return embedded.Kind() == reflect.Struct
+}
+
+func fieldKey(field reflect.StructField) string {
+	if getJsonapiFieldType(field) == "primary" {
+		return "\x00primary"
+	}
+	return getEncodedFieldName(field)
+}
+
+func isTaggedField(field reflect.StructField) bool {
+	if field.Tag.Get("jsonapi") != "" {
+		return true
+	}
+	return strings.Split(field.Tag.Get("json"), ",")[0] != ""
+}
+
+func isDominantField(field reflect.StructField, candidates []reflect.StructField) bool {
+	key := fieldKey(field)
+	depth := len(field.Index)
+	if depth == 1 {
+		//Own fields are never dropped, duplicates on the top level are reported by marshal as configuration errors
+		return true
+	}
+
+	sameDepth := make([]reflect.StructField, 0)
+	for _, candidate := range candidates {
+		if fieldKey(candidate) != key {
+			continue
+		}
+		if len(candidate.Index) < depth {
+			return false
+		}
+		if len(candidate.Index) == depth {
+			sameDepth = append(sameDepth, candidate)
+		}
+	}
+
+	if len(sameDepth) == 1 {
+		return true
+	}
+
+	tagged := 0
+	for _, candidate := range sameDepth {
+		if isTaggedField(candidate) {
+			tagged++
+		}
+	}
+	return tagged == 1 && isTaggedField(field)
+}
+
+// fieldByIndex follows the index path of a promoted field. ok is false if the path crosses a nil embedded pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// fieldByIndexAlloc follows the index path of a promoted field allocating nil embedded pointers on the way.
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
@@ -0,0 +1,265 @@
+package jsonapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FilterExpression is a node of the filter expression tree, either a FilterCondition leaf or a FilterGroup.
+type FilterExpression interface {
+	isFilterExpression()
+}
+
+type FilterLogic string
+
+const (
+	FilterAnd FilterLogic = "and"
+	FilterOr  FilterLogic = "or"
+)
+
+// FilterGroup combines expressions with AND or OR logic.
+type FilterGroup struct {
+	Logic       FilterLogic
+	Expressions []FilterExpression
+}
+
+func (FilterGroup) isFilterExpression() {}
+
+// FilterCondition is a single filter[field][operator]=value expression.
+type FilterCondition struct {
+	//Field is the attribute name, prefixed with relationship names separated by dots when targeting a related resource
+	Field string
+	//Operator is the operator name, "eq" if the parameter doesn't provide one
+	Operator string
+	//Value holds the value converted to the Go type of the attribute, or a slice of such values for list operators
+	Value interface{}
+}
+
+func (FilterCondition) isFilterExpression() {}
+
+type FilterOperator struct {
+	//List operators accept a comma separated list of values
+	List bool
+	//Cast replaces the default conversion to the attribute type, e.g. for operators that always take a boolean
+	Cast func(attributeType reflect.Type, raw string) (interface{}, error)
+}
+
+const DefaultFilterOperator = "eq"
+
+var DefaultFilterOperators = map[string]FilterOperator{
+	"eq":  {},
+	"ne":  {},
+	"gt":  {},
+	"gte": {},
+	"lt":  {},
+	"lte": {},
+	"in":  {List: true},
+	"nin": {List: true},
+}
+
+// ParseFilter converts filter[...] parameters into an expression tree of conditions typed after the model attributes.
+// Query parameters can only be combined with AND, so the root is an AND group of conditions. Applications are free to
+// nest it into groups of their own.
+// When operators is nil DefaultFilterOperators are used. Unknown fields, operators and values that cannot be converted
+// are reported at once as *ErrorsPayload.
+func ParseFilter(query url.Values, model reflect.Type, operators map[string]FilterOperator) (*FilterGroup, error) {
+	if operators == nil {
+		operators = DefaultFilterOperators
+	}
+
+	modelType, ok := structTypeOf(model)
+	if !ok {
+		return nil, errors.New("invalid model type")
+	}
+
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	conditions := make([]FilterExpression, 0)
+	errs := make([]*JSONAPIError, 0)
+
+	for _, key := range keys {
+		family, members, ok := splitParameterFamily(key)
+		if !ok {
+			if strings.HasPrefix(key, "filter") {
+				errs = append(errs, newParameterError(key, "Invalid filter", fmt.Sprintf("malformed parameter name %s", key)))
+			}
+			continue
+		}
+		if family != "filter" {
+			continue
+		}
+		if len(members) == 0 || len(members) > 2 || members[0] == "" {
+			errs = append(errs, newParameterError(key, "Invalid filter", "filter parameter must be in filter[field] or filter[field][operator] form"))
+			continue
+		}
+
+		operatorName := DefaultFilterOperator
+		if len(members) == 2 {
+			operatorName = members[1]
+		}
+		operator, ok := operators[operatorName]
+		if !ok {
+			errs = append(errs, newParameterError(key, "Invalid filter", fmt.Sprintf("unknown filter operator %s", operatorName)))
+			continue
+		}
+
+		attributeType, err := filterAttributeType(modelType, members[0])
+		if err != nil {
+			errs = append(errs, newParameterError(key, "Invalid filter", err.Error()))
+			continue
+		}
+
+		for _, raw := range query[key] {
+			value, err := castFilterOperand(operator, attributeType, raw)
+			if err != nil {
+				errs = append(errs, newParameterError(key, "Invalid filter", fmt.Sprintf("invalid value for %s: %s", members[0], err)))
+				continue
+			}
+			conditions = append(conditions, FilterCondition{Field: members[0], Operator: operatorName, Value: value})
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, &ErrorsPayload{Errors: errs}
+	}
+
+	return &FilterGroup{Logic: FilterAnd, Expressions: conditions}, nil
+}
+
+// filterAttributeType resolves the Go type of the attribute targeted by the field path.
+// Pointers are unwrapped, and slices resolve to their element type so that values can be matched against list members.
+func filterAttributeType(modelType reflect.Type, field string) (reflect.Type, error) {
+	segments := strings.Split(field, ".")
+	target, err := walkRelationshipPath(modelType, segments[:len(segments)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	attributes, _ := resourceSchema(target)
+	attribute, ok := attributes[segments[len(segments)-1]]
+	if !ok {
+		return nil, fmt.Errorf("unknown filter field %s", field)
+	}
+
+	attributeType := attribute.Type
+	for attributeType.Kind() == reflect.Pointer {
+		attributeType = attributeType.Elem()
+	}
+	if attributeType.Kind() == reflect.Slice && attributeType.Elem().Kind() != reflect.Uint8 {
+		attributeType = attributeType.Elem()
+		for attributeType.Kind() == reflect.Pointer {
+			attributeType = attributeType.Elem()
+		}
+	}
+	return attributeType, nil
+}
+
+func castFilterOperand(operator FilterOperator, attributeType reflect.Type, raw string) (interface{}, error) {
+	cast := operator.Cast
+	if cast == nil {
+		cast = castFilterValue
+	}
+
+	if !operator.List {
+		return cast(attributeType, raw)
+	}
+
+	list := reflect.MakeSlice(reflect.SliceOf(attributeType), 0, 0)
+	for _, item := range strings.Split(raw, ",") {
+		value, err := cast(attributeType, item)
+		if err != nil {
+			return nil, err
+		}
+		valueOf := reflect.ValueOf(value)
+		if !valueOf.IsValid() || !valueOf.Type().AssignableTo(attributeType) {
+			//Custom casts are free to return any type, fall back to a loosely typed list
+			return castFilterList(cast, attributeType, raw)
+		}
+		list = reflect.Append(list, valueOf)
+	}
+	return list.Interface(), nil
+}
+
+func castFilterList(cast func(reflect.Type, string) (interface{}, error), attributeType reflect.Type, raw string) (interface{}, error) {
+	out := make([]interface{}, 0)
+	for _, item := range strings.Split(raw, ",") {
+		value, err := cast(attributeType, item)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, value)
+	}
+	return out, nil
+}
+
+// castFilterValue converts raw query value into the attribute type by feeding castPrimitive with
+// the same representation it would receive from a decoded JSON document.
+func castFilterValue(attributeType reflect.Type, raw string) (value interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			switch r.(type) {
+			case error:
+				err = r.(error)
+			default:
+				err = fmt.Errorf("%v", r)
+			}
+		}
+	}()
+
+	var attribute interface{}
+
+	stringUnmarshallerType := reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	switch {
+	case reflect.PointerTo(attributeType).Implements(stringUnmarshallerType):
+		v := reflect.New(attributeType)
+		if err := v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw)); err != nil {
+			return nil, err
+		}
+		return v.Elem().Interface(), nil
+	case attributeType == jsonNumberType:
+		attribute, err = parseNumberLiteral(raw)
+	default:
+		switch attributeType.Kind() {
+		case reflect.Bool:
+			attribute, err = strconv.ParseBool(raw)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			attribute, err = parseNumberLiteral(raw)
+		case reflect.String:
+			attribute = raw
+		default:
+			return nil, fmt.Errorf("filtering by %s is not supported", attributeType)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	converted := castPrimitive(attributeType.Kind(), attributeType, attribute)
+	if converted.Type() != attributeType && converted.Type().ConvertibleTo(attributeType) {
+		//Named types on top of primitives, e.g. type Status string
+		converted = converted.Convert(attributeType)
+	}
+	return converted.Interface(), nil
+}
+
+// parseNumberLiteral accepts the value only if it is a valid JSON number, so that it can be converted like a decoded document.
+func parseNumberLiteral(raw string) (json.Number, error) {
+	var number json.Number
+	if err := json.Unmarshal([]byte(raw), &number); err != nil || strings.HasPrefix(raw, `"`) {
+		return "", fmt.Errorf("%s is not a number", raw)
+	}
+	return number, nil
+}
@@ -0,0 +1,148 @@
+package jsonapi
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// filterLabel validates filter values without holding them, through a value receiver.
+type filterLabel struct {
+	Name string
+}
+
+func (filterLabel) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		return errors.New("empty label")
+	}
+	return nil
+}
+
+func TestParseFilter(t *testing.T) {
+	type Status string
+
+	type Person struct {
+		ID   string `jsonapi:"primary,people"`
+		Name string `jsonapi:"attr,name"`
+	}
+
+	type Article struct {
+		ID       string             `jsonapi:"primary,articles"`
+		Age      int                `jsonapi:"attr,age"`
+		Score    *float64           `jsonapi:"attr,score"`
+		Name     string             `jsonapi:"attr,name"`
+		Status   Status             `jsonapi:"attr,status"`
+		Tags     []string           `jsonapi:"attr,tags"`
+		Created  time.Time          `jsonapi:"attr,created"`
+		Hash     StringSerializable `jsonapi:"attr,hash"`
+		Archived bool               `jsonapi:"attr,archived"`
+		Author   *Person            `jsonapi:"relation,author"`
+	}
+
+	t.Run("should convert values to the attribute types", func(t *testing.T) {
+		query, err := url.ParseQuery("filter[age][gte]=18&filter[score][lt]=2.5&filter[name][in]=a,b&filter[status]=draft&filter[tags]=go&filter[created][gt]=2024-01-02T00:00:00Z&filter[hash]=01020304&filter[archived]=true&filter[author.name]=john&sort=age")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out, err := ParseFilter(query, reflect.TypeOf(new(Article)), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := &FilterGroup{Logic: FilterAnd, Expressions: []FilterExpression{
+			FilterCondition{Field: "age", Operator: "gte", Value: 18},
+			FilterCondition{Field: "archived", Operator: "eq", Value: true},
+			FilterCondition{Field: "author.name", Operator: "eq", Value: "john"},
+			FilterCondition{Field: "created", Operator: "gt", Value: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+			FilterCondition{Field: "hash", Operator: "eq", Value: StringSerializable{1, 2, 3, 4}},
+			FilterCondition{Field: "name", Operator: "in", Value: []string{"a", "b"}},
+			FilterCondition{Field: "score", Operator: "lt", Value: 2.5},
+			FilterCondition{Field: "status", Operator: "eq", Value: Status("draft")},
+			FilterCondition{Field: "tags", Operator: "eq", Value: "go"},
+		}}
+
+		if !reflect.DeepEqual(out, expected) {
+			t.Errorf("expected %+v, got %+v", expected, out)
+		}
+	})
+
+	t.Run("should accept custom operators", func(t *testing.T) {
+		operators := map[string]FilterOperator{
+			"eq": {},
+			"exists": {Cast: func(attributeType reflect.Type, raw string) (interface{}, error) {
+				return raw == "true", nil
+			}},
+		}
+
+		out, err := ParseFilter(url.Values{"filter[age][exists]": {"true"}}, reflect.TypeOf(Article{}), operators)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []FilterExpression{FilterCondition{Field: "age", Operator: "exists", Value: true}}
+		if !reflect.DeepEqual(out.Expressions, expected) {
+			t.Errorf("unexpected conditions %+v", out)
+		}
+
+		_, err = ParseFilter(url.Values{"filter[age][gte]": {"1"}}, reflect.TypeOf(Article{}), operators)
+		if err == nil {
+			t.Errorf("expected error for operator outside of the provided set")
+		}
+	})
+
+	t.Run("should convert values of text unmarshalers with value receivers", func(t *testing.T) {
+		type Labeled struct {
+			ID    string      `jsonapi:"primary,labeled"`
+			Label filterLabel `jsonapi:"attr,label"`
+		}
+
+		out, err := ParseFilter(url.Values{"filter[label]": {"x"}}, reflect.TypeOf(Labeled{}), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := []FilterExpression{FilterCondition{Field: "label", Operator: "eq", Value: filterLabel{}}}
+		if !reflect.DeepEqual(out.Expressions, expected) {
+			t.Errorf("unexpected conditions %+v", out)
+		}
+
+		_, err = ParseFilter(url.Values{"filter[label]": {""}}, reflect.TypeOf(Labeled{}), nil)
+		if err == nil || !strings.Contains(err.Error(), "empty label") {
+			t.Errorf("expected unmarshaler error, got %v", err)
+		}
+	})
+
+	t.Run("should report unknown fields, operators and invalid values with source parameter", func(t *testing.T) {
+		query := url.Values{
+			"filter[agee]":         {"1"},
+			"filter[age][between]": {"1"},
+			"filter[age][gt]":      {"old"},
+			"filter[author.x]":     {"1"},
+			"filter[hash]":         {"zz"},
+			"filter":               {"1"},
+		}
+
+		_, err := ParseFilter(query, reflect.TypeOf(Article{}), nil)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+
+		var payload *ErrorsPayload
+		if !errors.As(err, &payload) {
+			t.Fatalf("expected *ErrorsPayload, got %T", err)
+		}
+
+		parameters := make([]string, 0)
+		for _, e := range payload.Errors {
+			parameters = append(parameters, e.Source["parameter"].(string))
+		}
+
+		expected := "filter,filter[age][between],filter[age][gt],filter[agee],filter[author.x],filter[hash]"
+		if strings.Join(parameters, ",") != expected {
+			t.Errorf("expected %s, got %s", expected, strings.Join(parameters, ","))
+		}
+	})
+}
@@ -0,0 +1,68 @@
+package jsonapi
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// UnmarshalAs is a typed counterpart of UnmarshalOneAsType.
+// Go type parameters cannot be restricted to struct types, so T is validated on every call and a non-struct T
+// is reported as an error.
+func UnmarshalAs[T any](payload []byte, opts ...Option) (*T, error) {
+	if err := checkResourceType[T](); err != nil {
+		return nil, err
+	}
+
+	out, err := UnmarshalOneAsType(payload, reflect.TypeFor[*T](), opts...)
+	if err != nil || out == nil {
+		return nil, err
+	}
+	return out.(*T), nil
+}
+
+// UnmarshalManyAs is a typed counterpart of UnmarshalManyAsType, see UnmarshalAs.
+func UnmarshalManyAs[T any](payload []byte, opts ...Option) ([]*T, error) {
+	if err := checkResourceType[T](); err != nil {
+		return nil, err
+	}
+
+	models, err := UnmarshalManyAsType(payload, reflect.TypeFor[*T](), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*T, len(models))
+	for i, model := range models {
+		out[i] = model.(*T)
+	}
+	return out, nil
+}
+
+// MarshalT is a typed counterpart of MarshalOne, see UnmarshalAs.
+func MarshalT[T any](in *T, opts ...Option) ([]byte, error) {
+	if err := checkResourceType[T](); err != nil {
+		return nil, err
+	}
+	return MarshalOne(in, opts...)
+}
+
+// MarshalManyT is a typed counterpart of MarshalMany, see UnmarshalAs.
+func MarshalManyT[T any](in []*T, opts ...Option) ([]byte, error) {
+	if err := checkResourceType[T](); err != nil {
+		return nil, err
+	}
+	for i, item := range in {
+		if item == nil {
+			return nil, fmt.Errorf("input element %d must not be nil", i)
+		}
+	}
+
+	return MarshalMany(in, opts...)
+}
+
+func checkResourceType[T any]() error {
+	if t := reflect.TypeFor[T](); t.Kind() != reflect.Struct {
+		return fmt.Errorf("invalid model type %s, resources must be structs", t)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package jsonapi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGeneric(t *testing.T) {
+
+	type SUT struct {
+		ID  string `jsonapi:"primary,test"`
+		Str string
+	}
+
+	t.Run("should round trip a single typed model", func(t *testing.T) {
+		input := &SUT{ID: "1", Str: "test"}
+
+		raw, err := MarshalT(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out, err := UnmarshalAs[SUT](raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if *out != *input {
+			t.Errorf("expected %+v, got %+v", input, out)
+		}
+	})
+
+	t.Run("should round trip a slice of typed models", func(t *testing.T) {
+		input := []*SUT{
+			{ID: "1", Str: "test"},
+			{ID: "2", Str: "test"},
+		}
+
+		raw, err := MarshalManyT(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out, err := UnmarshalManyAs[SUT](raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(out, input) {
+			t.Errorf("expected %+v, got %+v", input, out)
+		}
+	})
+
+	t.Run("should return errors for non struct types", func(t *testing.T) {
+		raw := []byte(`{"data":{"id":"1","type":"test"}}`)
+
+		if _, err := UnmarshalAs[string](raw); err == nil {
+			t.Fatal("expected error for string model")
+		}
+		if _, err := UnmarshalAs[*SUT](raw); err == nil {
+			t.Fatal("expected error for pointer model")
+		}
+		if _, err := UnmarshalManyAs[map[string]interface{}]([]byte(`{"data":[]}`)); err == nil {
+			t.Fatal("expected error for map model")
+		}
+		value := 1
+		if _, err := MarshalT(&value); err == nil {
+			t.Fatal("expected error for int model")
+		}
+	})
+
+	t.Run("should return errors for nil elements and invalid payloads", func(t *testing.T) {
+		if _, err := MarshalManyT([]*SUT{nil}); err == nil {
+			t.Fatal("expected error for nil element")
+		}
+		if _, err := UnmarshalAs[SUT]([]byte(`{"data":[]}`)); err == nil {
+			t.Fatal("expected error for collection payload")
+		}
+		if _, err := UnmarshalManyAs[SUT]([]byte(`{"data":{}}`)); err == nil {
+			t.Fatal("expected error for single resource payload")
+		}
+	})
+
+	t.Run("should round trip null primary data", func(t *testing.T) {
+		raw, err := MarshalT[SUT](nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(raw) != `{"data":null}` {
+			t.Fatal("unexpected payload", string(raw))
+		}
+
+		out, err := UnmarshalAs[SUT](raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != nil {
+			t.Fatal("expected nil model", out)
+		}
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestDataTypeIntegrity(t *testing.T) {
@@ -402,3 +403,169 @@ func TestStructTags(t *testing.T) {
 		}
 	})
 }
+
+func TestEmbeddedStructs(t *testing.T) {
+
+	type BaseModel struct {
+		ID        string    `jsonapi:"primary,users"`
+		CreatedAt time.Time `jsonapi:"attr,createdAt"`
+		UpdatedAt time.Time `jsonapi:"attr,updatedAt"`
+	}
+
+	type Owner struct {
+		BaseModel
+		Name string `jsonapi:"attr,name"`
+	}
+
+	type Audit struct {
+		Owner   *Owner `jsonapi:"relation,owner"`
+		Comment string `jsonapi:"attr,comment"`
+	}
+
+	type Address struct {
+		City string `json:"city"`
+	}
+
+	type SUT struct {
+		BaseModel
+		*Audit
+		Name    string `jsonapi:"attr,name"`
+		Address struct {
+			Address
+			Zip string `json:"zip"`
+		} `jsonapi:"attr,address"`
+	}
+
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("should promote attributes, relationships and primary key of embedded structs", func(t *testing.T) {
+		input := SUT{
+			BaseModel: BaseModel{ID: "1", CreatedAt: created},
+			Audit: &Audit{
+				Owner:   &Owner{BaseModel: BaseModel{ID: "2"}, Name: "owner"},
+				Comment: "audited",
+			},
+			Name: "sut",
+		}
+		input.Address.City = "Berlin"
+		input.Address.Zip = "10115"
+
+		raw, err := Marshal(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		check := map[string]interface{}{}
+		if err := json.Unmarshal(raw, &check); err != nil {
+			t.Fatal(err)
+		}
+
+		data := check["data"].(map[string]interface{})
+		if data["id"] != "1" || data["type"] != "users" {
+			t.Fatalf("unexpected resource identity %+v", data)
+		}
+
+		attrs := data["attributes"].(map[string]interface{})
+		if attrs["createdAt"] != created.Format(time.RFC3339) || attrs["comment"] != "audited" || attrs["name"] != "sut" {
+			t.Errorf("unexpected attributes %+v", attrs)
+		}
+		if _, ok := attrs["baseModel"]; ok {
+			t.Errorf("embedded struct should not be a nested attribute")
+		}
+		if !reflect.DeepEqual(attrs["address"], map[string]interface{}{"city": "Berlin", "zip": "10115"}) {
+			t.Errorf("unexpected nested attribute %+v", attrs["address"])
+		}
+
+		owner := data["relationships"].(map[string]interface{})["owner"].(map[string]interface{})["data"]
+		if !reflect.DeepEqual(owner, map[string]interface{}{"type": "users", "id": "2"}) {
+			t.Errorf("unexpected relationship %+v", owner)
+		}
+
+		out := SUT{}
+		if err := Unmarshal(raw, &out); err != nil {
+			t.Fatal(err)
+		}
+
+		if out.ID != "1" || !out.CreatedAt.Equal(created) || out.Name != "sut" || out.Address != input.Address {
+			t.Errorf("unexpected output %+v", out)
+		}
+		if out.Audit == nil || out.Comment != "audited" || out.Owner == nil || out.Owner.ID != "2" || out.Owner.Name != "owner" {
+			t.Errorf("expected embedded pointer to be allocated and filled in, got %+v", out.Audit)
+		}
+	})
+
+	t.Run("should skip nil embedded pointers", func(t *testing.T) {
+		raw, err := Marshal(SUT{BaseModel: BaseModel{ID: "1"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		check := map[string]interface{}{}
+		if err := json.Unmarshal(raw, &check); err != nil {
+			t.Fatal(err)
+		}
+
+		data := check["data"].(map[string]interface{})
+		if _, ok := data["attributes"].(map[string]interface{})["comment"]; ok {
+			t.Errorf("unexpected attribute promoted through nil pointer")
+		}
+		if _, ok := data["relationships"].(map[string]interface{})["owner"]; ok {
+			t.Errorf("unexpected relationship promoted through nil pointer")
+		}
+
+		out := SUT{}
+		if err := Unmarshal(raw, &out); err != nil {
+			t.Fatal(err)
+		}
+		if out.Audit != nil {
+			t.Errorf("expected embedded pointer to stay nil when none of its fields are present")
+		}
+	})
+
+	t.Run("should apply shadowing rules", func(t *testing.T) {
+		type Left struct {
+			Name  string `jsonapi:"attr,name"`
+			Label string `jsonapi:"attr,label"`
+		}
+		type Right struct {
+			Label string `jsonapi:"attr,label"`
+			Extra string `jsonapi:"attr,extra"`
+		}
+		type Shadowed struct {
+			BaseModel
+			Left
+			Right
+			ID   string `jsonapi:"primary,shadows"`
+			Name string `jsonapi:"attr,name"`
+		}
+
+		raw, err := Marshal(Shadowed{
+			BaseModel: BaseModel{ID: "base"},
+			Left:      Left{Name: "left", Label: "left"},
+			Right:     Right{Label: "right", Extra: "extra"},
+			ID:        "1",
+			Name:      "outer",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		check := map[string]interface{}{}
+		if err := json.Unmarshal(raw, &check); err != nil {
+			t.Fatal(err)
+		}
+
+		data := check["data"].(map[string]interface{})
+		if data["id"] != "1" || data["type"] != "shadows" {
+			t.Errorf("expected outer primary key to shadow the embedded one, got %+v", data)
+		}
+
+		attrs := data["attributes"].(map[string]interface{})
+		if attrs["name"] != "outer" || attrs["extra"] != "extra" {
+			t.Errorf("unexpected attributes %+v", attrs)
+		}
+		if _, ok := attrs["label"]; ok {
+			t.Errorf("conflicting fields on the same depth should be dropped")
+		}
+	})
+}
@@ -2,8 +2,11 @@ package jsonapi
 
 import (
 	"encoding"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"maps"
 	"reflect"
 	"slices"
 	"strconv"
@@ -11,80 +14,148 @@ import (
 	"time"
 )
 
+// includesCache collects included documents of a single Marshal call and remembers resources marshaled so far to
+// break out of reference loops. Resources are keyed by type and id along with their Go type and address, so that
+// different views of the same resource are still marshaled and merged. Values that are not addressable cannot be
+// part of a loop and are not remembered.
 type includesCache struct {
-	includes []reflect.Value
+	seen     map[includesKey]struct{}
+	includes []interface{}
 }
 
-func (c *includesCache) add(v reflect.Value) {
-	c.includes = append(c.includes, v)
+type includesKey struct {
+	resource  resourceKey
+	modelType reflect.Type
+	addr      uintptr
 }
 
-func (c *includesCache) contains(v reflect.Value) bool {
-	for _, i := range c.includes {
-		if i == v {
-			return true
-		}
+func newIncludesCache() *includesCache {
+	return &includesCache{seen: map[includesKey]struct{}{}}
+}
+
+func (c *includesCache) key(v reflect.Value, resourceType string, id string) (includesKey, bool) {
+	if !v.CanAddr() {
+		return includesKey{}, false
 	}
-	return false
+	return includesKey{
+		resource:  resourceKey{resourceType: resourceType, id: id},
+		modelType: v.Type(),
+		addr:      v.Addr().Pointer(),
+	}, true
 }
 
-func Marshal(in interface{}) ([]byte, error) {
-	inVal := reflect.ValueOf(in)
-	if inVal.Kind() == reflect.Ptr {
-		inVal = inVal.Elem()
+func (c *includesCache) add(v reflect.Value, resourceType string, id string) {
+	if key, ok := c.key(v, resourceType, id); ok {
+		c.seen[key] = struct{}{}
 	}
+}
 
-	if inVal.Kind() != reflect.Slice {
-		return MarshalOne(in)
+func (c *includesCache) contains(v reflect.Value, resourceType string, id string) bool {
+	key, ok := c.key(v, resourceType, id)
+	if !ok {
+		return false
 	}
+	_, ok = c.seen[key]
+	return ok
+}
 
-	return MarshalMany(in)
+func Marshal(in interface{}, opts ...Option) ([]byte, error) {
+	inType := reflect.TypeOf(in)
+	if inType != nil && inType.Kind() == reflect.Ptr {
+		inType = inType.Elem()
+	}
+
+	if inType == nil || inType.Kind() != reflect.Slice {
+		return MarshalOne(in, opts...)
+	}
+
+	return MarshalMany(in, opts...)
 }
 
-func MarshalMany(in interface{}) ([]byte, error) {
+func MarshalMany(in interface{}, opts ...Option) ([]byte, error) {
+	o := newOptions(opts)
 	inVal := reflect.ValueOf(in)
-	if inVal.Kind() == reflect.Ptr {
+	if inVal.Kind() == reflect.Ptr && !inVal.IsNil() {
 		inVal = inVal.Elem()
 	}
 
-	if inVal.Kind() != reflect.Slice {
+	switch {
+	case inVal.Kind() == reflect.Ptr && inVal.Type().Elem().Kind() == reflect.Slice:
+		//Nil pointer to a slice is an empty collection
+		return json.Marshal(map[string]interface{}{"data": []interface{}{}})
+	case inVal.Kind() != reflect.Slice:
 		return nil, errors.New("input must be a slice")
 	}
 
 	out := make([]map[string]interface{}, inVal.Len())
 
-	allIncludes := make([]interface{}, 0)
+	refcache := newIncludesCache()
 	for i := 0; i < inVal.Len(); i++ {
-		next, includes, err := marshalNode(inVal.Index(i).Interface(), &includesCache{})
+		element := inVal.Index(i)
+		if (element.Kind() == reflect.Interface || element.Kind() == reflect.Ptr) && element.IsNil() {
+			return nil, fmt.Errorf("input element %d must not be nil", i)
+		}
+
+		next, err := marshalNode(element.Interface(), refcache, o)
 		if err != nil {
 			return nil, err
 		}
 		out[i] = next
-		allIncludes = append(allIncludes, includes...)
 	}
+	allIncludes := refcache.includes
 
 	result := map[string]interface{}{
 		"data": out,
 	}
 
-	if len(allIncludes) > 0 {
-		result["included"] = deduplicateIncluded(allIncludes)
+	if len(allIncludes) > 0 && (!o.requestDocument || o.sideposting) {
+		included, err := deduplicateIncluded(allIncludes, o)
+		if err != nil {
+			return nil, err
+		}
+		included, err = excludePrimary(out, included, o)
+		if err != nil {
+			return nil, err
+		}
+		result["included"] = included
+	}
+	if o.requestDocument {
+		compactRequestDocument(result)
 	}
 	return json.Marshal(result)
 }
 
-func MarshalOne(in interface{}) ([]byte, error) {
-	doc, includes, err := marshalNode(in, &includesCache{})
+// MarshalOne marshals a single resource. Nil input, including a nil pointer, produces null primary data.
+func MarshalOne(in interface{}, opts ...Option) ([]byte, error) {
+	if inVal := reflect.ValueOf(in); !inVal.IsValid() || (inVal.Kind() == reflect.Ptr && inVal.IsNil()) {
+		return json.Marshal(map[string]interface{}{"data": nil})
+	}
+
+	o := newOptions(opts)
+	refcache := newIncludesCache()
+	doc, err := marshalNode(in, refcache, o)
 	if err != nil {
 		return nil, err
 	}
+	includes := refcache.includes
 
 	out := map[string]interface{}{
 		"data": doc,
 	}
 
-	if len(includes) > 0 {
-		out["included"] = deduplicateIncluded(includes)
+	if len(includes) > 0 && (!o.requestDocument || o.sideposting) {
+		included, err := deduplicateIncluded(includes, o)
+		if err != nil {
+			return nil, err
+		}
+		included, err = excludePrimary([]map[string]interface{}{doc}, included, o)
+		if err != nil {
+			return nil, err
+		}
+		out["included"] = included
+	}
+	if o.requestDocument {
+		compactRequestDocument(out)
 	}
 
 	return json.Marshal(out)
@@ -92,8 +163,7 @@ func MarshalOne(in interface{}) ([]byte, error) {
 
 func MixInMeta(source []byte, meta map[string]interface{}) ([]byte, error) {
 	raw := make(map[string]interface{})
-	err := json.Unmarshal(source, &raw)
-	if err != nil {
+	if err := decodeJSON(source, &raw); err != nil {
 		return nil, err
 	}
 
@@ -102,7 +172,12 @@ func MixInMeta(source []byte, meta map[string]interface{}) ([]byte, error) {
 	return json.Marshal(raw)
 }
 
-func marshalNode(node interface{}, refcache *includesCache) (map[string]interface{}, []interface{}, error) {
+func marshalNode(node interface{}, refcache *includesCache, opts *options) (map[string]interface{}, error) {
+	return marshalNodeAs(node, "", refcache, opts)
+}
+
+// marshalNodeAs marshals the resource with the given resource type, or the type of its primary tag if empty.
+func marshalNodeAs(node interface{}, resourceType string, refcache *includesCache, opts *options) (map[string]interface{}, error) {
 	inType := reflect.TypeOf(node)
 	inVal := reflect.ValueOf(node)
 
@@ -111,64 +186,49 @@ func marshalNode(node interface{}, refcache *includesCache) (map[string]interfac
 		inType = inType.Elem()
 	}
 
-	resourceType, err := getResourceType(inVal, inType)
-	if err != nil {
-		return nil, nil, err
+	if resourceType == "" {
+		var err error
+		if resourceType, err = getResourceType(inVal, inType); err != nil {
+			return nil, err
+		}
 	}
-	resourceId, err := getResourceID(inVal, inType)
+	resourceId, err := getResourceID(inVal, inType, opts)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-	resourceAttrs, err := getAttributes(inVal, inType)
+	refcache.add(inVal, resourceType, resourceId)
+
+	resourceAttrs, err := getAttributes(inVal, inType, opts)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-	resourceRelationships, includes, err := getRelationships(inVal, inType, refcache)
+	resourceRelationships, err := getRelationships(inVal, inType, refcache, opts)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	return map[string]interface{}{
+	out := map[string]interface{}{
 		"type":          resourceType,
-		"id":            resourceId,
 		"attributes":    resourceAttrs,
 		"relationships": resourceRelationships,
-	}, includes, nil
+	}
+	setIdentity(out, inVal, resourceId, opts)
+
+	return out, nil
 }
 
-func getResourceID(inVal reflect.Value, inType reflect.Type) (string, error) {
+func getResourceID(inVal reflect.Value, inType reflect.Type, opts *options) (string, error) {
 
-	for i, n := 0, inType.NumField(); i < n; i++ {
-		field := inType.Field(i)
+	for _, field := range resourceFields(inType) {
 		tag := field.Tag.Get("jsonapi")
 		if tag != "" {
 			parts := strings.Split(tag, ",")
-			if len(parts) > 1 && parts[0] == "primary" {
-				idField := inVal.FieldByName(field.Name)
-
-				if idField.IsValid() {
-					f := inVal.FieldByName("ID")
-					switch f.Kind() {
-					case reflect.String:
-						return f.String(), nil
-					case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-						return strconv.FormatInt(f.Int(), 10), nil
-					case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-						return strconv.FormatUint(f.Uint(), 10), nil
-					case reflect.Float32, reflect.Float64:
-						return strconv.FormatFloat(f.Float(), 'f', -1, 64), nil
-					default:
-						textable, ok := f.Interface().(encoding.TextMarshaler)
-						if ok {
-							b, err := textable.MarshalText()
-							if err != nil {
-								return "", err
-							}
-							return string(b), nil
-						}
-						return "", errors.New("ID field must be a string, number or implement encoding.TextMarshaler")
-					}
+			if parts[0] == "primary" {
+				idField, ok := fieldByIndex(inVal, field.Index)
+				if !ok { //Primary key promoted through a nil embedded pointer
+					return "", nil
 				}
+				return formatIDWithCodec(idField, opts)
 			}
 		}
 	}
@@ -176,44 +236,136 @@ func getResourceID(inVal reflect.Value, inType reflect.Type) (string, error) {
 	return "", errors.New("no primary key found")
 }
 
-func getResourceType(inVal reflect.Value, inType reflect.Type) (string, error) {
-	idField := inVal.FieldByName("ID")
-	if idField.IsValid() {
-		fType, _ := inType.FieldByName("ID")
-		tag := fType.Tag.Get("jsonapi")
-		if tag != "" {
-			parts := strings.Split(tag, ",")
-			if len(parts) > 1 && parts[0] == "primary" {
-				return parts[1], nil
+func formatIDWithCodec(f reflect.Value, opts *options) (string, error) {
+	if id, isHandled, err := encodeIDWithCodec(f, opts); isHandled {
+		return id, err
+	}
+	return formatID(f)
+}
+
+func formatID(f reflect.Value) (string, error) {
+	switch f.Kind() {
+	case reflect.String:
+		return f.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(f.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(f.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(f.Float(), 'f', -1, 64), nil
+	case reflect.Pointer:
+		if f.IsNil() {
+			return "", nil
+		}
+		if textable, ok := f.Interface().(encoding.TextMarshaler); ok {
+			return marshalIDText(textable)
+		}
+		return formatID(f.Elem())
+	default:
+		if textable, ok := f.Interface().(encoding.TextMarshaler); ok {
+			return marshalIDText(textable)
+		}
+		if f.CanAddr() {
+			if textable, ok := f.Addr().Interface().(encoding.TextMarshaler); ok {
+				return marshalIDText(textable)
 			}
 		}
+		if isByteArray(f.Type()) {
+			//Raw binary ids, e.g. UUID [16]byte, are encoded as lowercase hex
+			b := make([]byte, f.Len())
+			reflect.Copy(reflect.ValueOf(b), f)
+			return hex.EncodeToString(b), nil
+		}
+		return "", errors.New("ID field must be a string, number, byte array or implement encoding.TextMarshaler")
+	}
+}
+
+func marshalIDText(textable encoding.TextMarshaler) (string, error) {
+	b, err := textable.MarshalText()
+	if err != nil {
+		return "", err
 	}
+	return string(b), nil
+}
+
+func isByteArray(t reflect.Type) bool {
+	return t.Kind() == reflect.Array && t.Elem().Kind() == reflect.Uint8
+}
+
+var (
+	errNoPrimaryKey   = errors.New("no primary key found")
+	errNoResourceType = errors.New("no resource type")
+)
 
-	for i, n := 0, inType.NumField(); i < n; i++ {
-		field := inType.Field(i)
+// ResourceTyper is implemented by models deciding their resource type at runtime, e.g. generic wrappers or structs
+// shared by several resource types. The method takes precedence over the primary tag, which may then omit the type:
+// jsonapi:"primary". Returning an empty string falls back to the tag.
+type ResourceTyper interface {
+	JSONAPIType() string
+}
+
+var resourceTyperType = reflect.TypeOf((*ResourceTyper)(nil)).Elem()
+
+func resourceTypeOf(v reflect.Value) (string, bool) {
+	if !v.CanInterface() {
+		return "", false
+	}
+
+	var typer ResourceTyper
+	switch {
+	case v.CanAddr() && v.Addr().Type().Implements(resourceTyperType):
+		typer = v.Addr().Interface().(ResourceTyper)
+	case v.Type().Implements(resourceTyperType):
+		typer = v.Interface().(ResourceTyper)
+	case reflect.PointerTo(v.Type()).Implements(resourceTyperType): //Pointer receiver on a value that is not addressable
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		typer = ptr.Interface().(ResourceTyper)
+	default:
+		return "", false
+	}
+
+	resourceType := typer.JSONAPIType()
+	return resourceType, resourceType != ""
+}
+
+// getResourceType returns the type of the resource from its JSONAPIType method if implemented, or from the primary tag.
+func getResourceType(inVal reflect.Value, inType reflect.Type) (string, error) {
+	for _, field := range resourceFields(inType) {
 		tag := field.Tag.Get("jsonapi")
 		if tag != "" {
 			parts := strings.Split(tag, ",")
-			if len(parts) > 1 && parts[0] == "primary" {
-				return parts[1], nil
+			if parts[0] != "primary" {
+				continue
+			}
+			if resourceType, ok := resourceTypeOf(inVal); ok {
+				return resourceType, nil
+			}
+			if len(parts) < 2 || parts[1] == "" {
+				return "", fmt.Errorf("%w on %s, set it in the primary tag or implement ResourceTyper", errNoResourceType, inType)
 			}
+			return parts[1], nil
 		}
 	}
 
-	return "", errors.New("no primary key found")
+	return "", errNoPrimaryKey
 }
 
-func getAttributes(inVal reflect.Value, inType reflect.Type) (map[string]interface{}, error) {
+func getAttributes(inVal reflect.Value, inType reflect.Type, opts *options) (map[string]interface{}, error) {
 	attrs := map[string]interface{}{}
 
-	for i, n := 0, inType.NumField(); i < n; i++ {
-		field := inType.Field(i)
+	for _, field := range resourceFields(inType) {
 		encodedFieldName := getEncodedFieldName(field)
 
 		if encodedFieldName == "-" {
 			continue
 		}
 
+		val, ok := fieldByIndex(inVal, field.Index)
+		if !ok { //Promoted through a nil embedded pointer
+			continue
+		}
+
 		jsonapiTag := field.Tag.Get("jsonapi")
 		jsonTag := field.Tag.Get("json")
 		if jsonapiTag != "" {
@@ -225,25 +377,34 @@ func getAttributes(inVal reflect.Value, inType reflect.Type) (map[string]interfa
 				continue
 			}
 
-			val := inVal.Field(i)
 			if strings.Contains(jsonapiTag, ",omitempty") && isEmptyValue(val) {
 				continue
 			}
 
-			attrs[encodedFieldName] = prepareAttributesNode(val)
+			attr, err := prepareAttributesNode(val, opts.forField(field))
+			if err != nil {
+				return nil, fmt.Errorf("marshal attribute %s: %w", encodedFieldName, err)
+			}
+			attrs[encodedFieldName] = attr
 
 		} else if jsonTag != "" {
-			val := inVal.Field(i)
-
 			if strings.Contains(jsonTag, ",omitempty") && isEmptyValue(val) {
 				continue
 			}
 
-			attrs[encodedFieldName] = prepareAttributesNode(val)
+			attr, err := prepareAttributesNode(val, opts.forField(field))
+			if err != nil {
+				return nil, fmt.Errorf("marshal attribute %s: %w", encodedFieldName, err)
+			}
+			attrs[encodedFieldName] = attr
 		} else if field.IsExported() {
 			//Exported field could represent an attribute or a relationship
 			//Attribute values could have nested structs
-			attrs[encodedFieldName] = prepareAttributesNode(inVal.Field(i))
+			attr, err := prepareAttributesNode(val, opts.forField(field))
+			if err != nil {
+				return nil, fmt.Errorf("marshal attribute %s: %w", encodedFieldName, err)
+			}
+			attrs[encodedFieldName] = attr
 		}
 	}
 
@@ -288,75 +449,136 @@ func getEncodedFieldName(field reflect.StructField) string {
 	return toCamelCase(field.Name)
 }
 
-func prepareAttributesNode(field reflect.Value) interface{} {
+func prepareAttributesNode(field reflect.Value, opts *options) (interface{}, error) {
+	if encoded, isHandled, err := encodeWithCodec(field, opts); isHandled {
+		return encoded, err
+	}
 
 	switch field.Kind() {
 	case reflect.Struct:
 		if field.Type() == reflect.TypeOf(time.Time{}) {
-			return field.Interface().(time.Time).Format(time.RFC3339)
+			return formatTime(field.Interface().(time.Time), opts), nil
 		}
-		_, isMarshaler := field.Interface().(json.Marshaler)
-		if isMarshaler {
-			return field.Interface()
+		if encoded, ok := encodeBigFloat(field); ok {
+			return encoded, nil
 		}
-
-		embed := map[string]interface{}{}
-
-		for i, n := 0, field.NumField(); i < n; i++ {
-			encodedFieldName := getEncodedFieldName(field.Type().Field(i))
-			if encodedFieldName == "-" {
-				continue
-			}
-			embed[encodedFieldName] = prepareAttributesNode(field.Field(i))
+		if reflect.PointerTo(field.Type()).Implements(reflect.TypeOf((*json.Marshaler)(nil)).Elem()) {
+			//Marshalers with pointer receivers such as big.Int are only picked up by encoding/json through a pointer
+			ptr := reflect.New(field.Type())
+			ptr.Elem().Set(field)
+			return ptr.Interface(), nil
 		}
-		return embed
+
+		return prepareNestedAttributes(field, opts)
 	case reflect.Pointer:
 		if field.Elem().Kind() != reflect.Struct {
-			return field.Interface()
+			return field.Interface(), nil
 		}
 
 		if field.Elem().Type() == reflect.TypeOf(time.Time{}) {
-			return field.Interface().(*time.Time).Format(time.RFC3339)
+			return formatTime(*field.Interface().(*time.Time), opts), nil
 		}
-		_, isMarshaler := field.Elem().Interface().(json.Marshaler)
-		if isMarshaler {
-			return field.Interface()
+		if encoded, ok := encodeBigFloat(field.Elem()); ok {
+			return encoded, nil
 		}
-
-		embed := map[string]interface{}{}
-		for i, n := 0, field.Elem().NumField(); i < n; i++ {
-			encodedFieldName := getEncodedFieldName(field.Elem().Type().Field(i))
-			if encodedFieldName == "-" {
-				continue
-			}
-			embed[encodedFieldName] = prepareAttributesNode(field.Elem().Field(i))
+		if field.Type().Implements(reflect.TypeOf((*json.Marshaler)(nil)).Elem()) {
+			return field.Interface(), nil
 		}
-		return embed
+
+		return prepareNestedAttributes(field.Elem(), opts)
 	case reflect.Slice:
 		embed := make([]interface{}, field.Len())
 		for i := 0; i < field.Len(); i++ {
-			embed[i] = prepareAttributesNode(field.Index(i))
+			next, err := prepareAttributesNode(field.Index(i), opts)
+			if err != nil {
+				return nil, err
+			}
+			embed[i] = next
+		}
+		return embed, nil
+	case reflect.Map:
+		if field.IsNil() || !opts.hasCodec(field.Type().Elem()) {
+			return field.Interface(), nil
 		}
-		return embed
+		//Only map values with a registered codec have to be prepared, encoding/json deals with the rest
+		embed := make(map[string]interface{}, field.Len())
+		iter := field.MapRange()
+		for iter.Next() {
+			key, err := formatMapKey(iter.Key())
+			if err != nil {
+				return nil, err
+			}
+			next, err := prepareAttributesNode(iter.Value(), opts)
+			if err != nil {
+				return nil, err
+			}
+			embed[key] = next
+		}
+		return embed, nil
 	default:
-		return field.Interface()
+		return field.Interface(), nil
 	}
 }
 
-func getRelationships(inVal reflect.Value, inType reflect.Type, refcache *includesCache) (map[string]interface{}, []interface{}, error) {
+// formatMapKey follows encoding/json rules for map keys
+func formatMapKey(key reflect.Value) (string, error) {
+	if key.Kind() == reflect.String {
+		return key.String(), nil
+	}
+	if textable, ok := key.Interface().(encoding.TextMarshaler); ok {
+		b, err := textable.MarshalText()
+		return string(b), err
+	}
+	return formatID(key)
+}
+
+func formatTime(t time.Time, opts *options) interface{} {
+	switch opts.timeFormat {
+	case timeFormatUnix:
+		return t.Unix()
+	case timeFormatUnixMilli:
+		return t.UnixMilli()
+	default:
+		return t.Format(opts.timeFormat)
+	}
+}
+
+func prepareNestedAttributes(field reflect.Value, opts *options) (map[string]interface{}, error) {
+	embed := map[string]interface{}{}
+	for _, nestedField := range resourceFields(field.Type()) {
+		encodedFieldName := getEncodedFieldName(nestedField)
+		if encodedFieldName == "-" {
+			continue
+		}
+		nestedVal, ok := fieldByIndex(field, nestedField.Index)
+		if !ok {
+			continue
+		}
+		next, err := prepareAttributesNode(nestedVal, opts.forField(nestedField))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", encodedFieldName, err)
+		}
+		embed[encodedFieldName] = next
+	}
+	return embed, nil
+}
+
+func getRelationships(inVal reflect.Value, inType reflect.Type, refcache *includesCache, opts *options) (map[string]interface{}, error) {
 	seen := make([]string, 0)
 	rels := map[string]interface{}{}
-	includes := make([]interface{}, 0)
 
-	for i, n := 0, inType.NumField(); i < n; i++ {
-		field := inType.Field(i)
+	for _, field := range resourceFields(inType) {
 		tag := field.Tag.Get("jsonapi")
 		if tag != "" {
 			parts := strings.Split(tag, ",")
 			if len(parts) > 0 && parts[0] == "relation" {
-				inner, include, err := prepareRelationshipNode(inVal.Field(i), refcache)
+				fieldVal, ok := fieldByIndex(inVal, field.Index)
+				if !ok {
+					continue
+				}
+				relationship, err := prepareRelationship(field, fieldVal, refcache, opts)
 				if err != nil {
-					return nil, nil, err
+					return nil, err
 				}
 
 				var relationshipName string
@@ -367,111 +589,207 @@ func getRelationships(inVal reflect.Value, inType reflect.Type, refcache *includ
 				}
 
 				if slices.Contains(seen, relationshipName) {
-					return nil, nil, errors.New("relationship name already used: " + relationshipName)
+					return nil, errors.New("relationship name already used: " + relationshipName)
 				}
 				seen = append(seen, relationshipName)
 
-				rels[relationshipName] = map[string]interface{}{
-					"data": inner,
+				if relationship == nil {
+					continue
 				}
-
-				includes = append(includes, include...)
+				rels[relationshipName] = relationship
 			}
 		}
 	}
 
-	return rels, includes, nil
+	return rels, nil
 }
 
-func prepareRelationshipNode(topFieldValue reflect.Value, refcache *includesCache) (interface{}, []interface{}, error) {
+// prepareRelationshipNode builds linkage of the related resources and adds their included documents to refcache. Phantom
+// resources only holding an id are linked but not included, unless the tag has include option or the resource
+// implements Includer.
+func prepareRelationshipNode(topFieldValue reflect.Value, tag relationTag, refcache *includesCache, opts *options) (interface{}, error) {
 	switch topFieldValue.Kind() {
 	case reflect.Pointer:
-		return prepareRelationshipNode(topFieldValue.Elem(), refcache)
+		return prepareRelationshipNode(topFieldValue.Elem(), tag, refcache, opts)
+	case reflect.Interface:
+		if topFieldValue.IsNil() {
+			return nil, nil
+		}
+		if !isResourceValue(topFieldValue.Elem()) {
+			return nil, fmt.Errorf("relationship value of type %s is not a resource", topFieldValue.Elem().Type())
+		}
+		return prepareRelationshipNode(topFieldValue.Elem(), tag, refcache, opts)
 	case reflect.Struct:
-		refType, err := getResourceType(topFieldValue, topFieldValue.Type())
-		if err != nil {
-			return nil, nil, err
+		refType := tag.typeOverride
+		if refType == "" {
+			var err error
+			if refType, err = getResourceType(topFieldValue, topFieldValue.Type()); err != nil {
+				return nil, err
+			}
 		}
-		refId, err := getResourceID(topFieldValue, topFieldValue.Type())
+		refId, err := getResourceID(topFieldValue, topFieldValue.Type(), opts)
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 
 		relation := map[string]interface{}{
 			"type": refType,
-			"id":   refId,
+		}
+		setIdentity(relation, topFieldValue, refId, opts)
+
+		if !tag.include && !includesPhantom(topFieldValue) && isPhantom(topFieldValue) {
+			return relation, nil
 		}
 
 		//Breaks out of infinite recursion if there's a closed references loop in the provided structure
-		if refcache.contains(topFieldValue) {
-			return relation, nil, nil
+		if refcache.contains(topFieldValue, refType, refId) {
+			return relation, nil
 		}
-		refcache.add(topFieldValue)
 
-		includeNode, internalIncludes, err := marshalNode(topFieldValue.Interface(), refcache)
+		node := topFieldValue.Interface()
+		if topFieldValue.CanAddr() { //Keeps the address for the cache
+			node = topFieldValue.Addr().Interface()
+		}
+		includeNode, err := marshalNodeAs(node, tag.typeOverride, refcache, opts)
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
+		refcache.includes = append(refcache.includes, includeNode)
 
-		return relation, append(internalIncludes, includeNode), nil
+		return relation, nil
 
 	case reflect.Slice:
 		embed := make([]interface{}, topFieldValue.Len())
-		includes := make([]interface{}, 0)
 		for i := 0; i < topFieldValue.Len(); i++ {
-			next, include, err := prepareRelationshipNode(topFieldValue.Index(i), refcache)
+			item := topFieldValue.Index(i)
+			if item.Kind() == reflect.Interface && (item.IsNil() || item.Elem().Kind() == reflect.Pointer && item.Elem().IsNil()) {
+				return nil, fmt.Errorf("relationship element %d must not be nil", i)
+			}
+			next, err := prepareRelationshipNode(item, tag, refcache, opts)
 			if err != nil {
-				return nil, nil, err
+				return nil, err
 			}
 			embed[i] = next
-			includes = append(includes, include...)
 		}
-		return embed, includes, nil
+		return embed, nil
 	default:
-		return nil, nil, nil
+		return nil, nil
 	}
 }
 
-func deduplicateIncluded(includes []interface{}) []interface{} {
-	unique := map[string]interface{}{}
-	out := make([]interface{}, 0)
+func deduplicateIncluded(includes []interface{}, opts *options) ([]interface{}, error) {
+	unique := map[resourceKey]map[string]interface{}{}
+	order := make([]resourceKey, 0)
 
 	for _, include := range includes {
 		doc := include.(map[string]interface{})
-		resourceType := doc["type"]
-		resourceId := doc["id"]
-		if resourceId == "" {
+		key, ok := resourceKeyOf(doc)
+		if !ok {
 			continue
 		}
 
-		key := resourceType.(string) + resourceId.(string)
+		existing, ok := unique[key]
+		if !ok {
+			unique[key] = doc
+			order = append(order, key)
+			continue
+		}
 
-		if _, ok := unique[key]; ok {
-			attributesLeft := doc["attributes"].(map[string]interface{})
-			attributesRight := unique[key].(map[string]interface{})["attributes"].(map[string]interface{})
-			mergedAttributes := shallowMerge(attributesLeft, attributesRight, isAttributeZero)
+		mergedAttributes, err := mergeMembers(key, "attribute", existing["attributes"].(map[string]interface{}), doc["attributes"].(map[string]interface{}), isAttributeZero, opts.includedMerge)
+		if err != nil {
+			return nil, err
+		}
 
-			relationshipsLeft := doc["relationships"].(map[string]interface{})
-			relationshipsRight := unique[key].(map[string]interface{})["relationships"].(map[string]interface{})
-			mergedRelationships := shallowMerge(relationshipsLeft, relationshipsRight, isRelationshipZero)
+		mergedRelationships, err := mergeMembers(key, "relationship", existing["relationships"].(map[string]interface{}), doc["relationships"].(map[string]interface{}), isRelationshipZero, opts.includedMerge)
+		if err != nil {
+			return nil, err
+		}
 
-			unique[key] = map[string]interface{}{
-				"type":          resourceType,
-				"id":            resourceId,
-				"attributes":    mergedAttributes,
-				"relationships": mergedRelationships,
-			}
+		merged := maps.Clone(existing)
+		merged["attributes"] = mergedAttributes
+		merged["relationships"] = mergedRelationships
+		unique[key] = merged
+	}
 
-		} else {
-			unique[key] = doc
+	out := make([]interface{}, len(order))
+	for i, key := range order {
+		out[i] = unique[key]
+	}
+
+	return out, nil
+}
+
+// excludePrimary drops resources present in primary data from included, filling in zero values of the primary
+// resources from the included views. Non-zero values of the primary resources win unless the merge is strict.
+func excludePrimary(primary []map[string]interface{}, included []interface{}, opts *options) ([]interface{}, error) {
+	index := make(map[resourceKey]map[string]interface{}, len(primary))
+	for _, doc := range primary {
+		if key, ok := resourceKeyOf(doc); ok {
+			index[key] = doc
 		}
 	}
 
-	for _, v := range unique {
-		out = append(out, v)
+	out := make([]interface{}, 0, len(included))
+	for _, include := range included {
+		view := include.(map[string]interface{})
+		key, _ := resourceKeyOf(view)
+		doc, ok := index[key]
+		if !ok {
+			out = append(out, include)
+			continue
+		}
+
+		mode := IncludedMergeFirstWins
+		if opts.includedMerge == IncludedMergeStrict {
+			mode = IncludedMergeStrict
+		}
+
+		attributes, err := mergeMembers(key, "attribute", doc["attributes"].(map[string]interface{}), view["attributes"].(map[string]interface{}), isAttributeZero, mode)
+		if err != nil {
+			return nil, err
+		}
+		relationships, err := mergeMembers(key, "relationship", doc["relationships"].(map[string]interface{}), view["relationships"].(map[string]interface{}), isRelationshipZero, mode)
+		if err != nil {
+			return nil, err
+		}
+		doc["attributes"] = attributes
+		doc["relationships"] = relationships
 	}
+	return out, nil
+}
 
-	return out
+// IncludedConflictError is returned in IncludedMergeStrict mode when two views of an included resource disagree.
+type IncludedConflictError struct {
+	Type string
+	ID   string
+	//Kind is either attribute or relationship
+	Kind   string
+	Member string
+	First  interface{}
+	Last   interface{}
+}
+
+func (e *IncludedConflictError) Error() string {
+	return fmt.Sprintf("conflicting values of %s %s in included resource %s/%s: %v and %v", e.Kind, e.Member, e.Type, e.ID, e.First, e.Last)
+}
+
+// mergeMembers merges attributes or relationships of an earlier and a later view of the same resource.
+func mergeMembers(key resourceKey, kind string, earlier, later map[string]interface{}, isZero zeroPredicate, mode IncludedMerge) (map[string]interface{}, error) {
+	switch mode {
+	case IncludedMergeFirstWins:
+		return shallowMerge(later, earlier, isZero), nil
+	case IncludedMergeStrict:
+		for name, left := range earlier {
+			right, ok := later[name]
+			if !ok || isZero(left) || isZero(right) {
+				continue
+			}
+			if !reflect.DeepEqual(left, right) {
+				return nil, &IncludedConflictError{Type: key.resourceType, ID: key.id, Kind: kind, Member: name, First: left, Last: right}
+			}
+		}
+	}
+	return shallowMerge(earlier, later, isZero), nil
 }
 
 type zeroPredicate func(i interface{}) bool
@@ -2,6 +2,8 @@ package jsonapi
 
 import (
 	"encoding/json"
+	"errors"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -866,6 +868,41 @@ func TestMarshalMany(t *testing.T) {
 	})
 }
 
+func TestMarshalEmptyPrimaryData(t *testing.T) {
+	type Test struct {
+		ID string `jsonapi:"primary,test"`
+	}
+
+	t.Run("should marshal nil input as null data", func(t *testing.T) {
+		var ptr *Test
+		for _, input := range []interface{}{nil, ptr} {
+			for _, marshal := range []func(interface{}, ...Option) ([]byte, error){Marshal, MarshalOne} {
+				raw, err := marshal(input)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(raw) != `{"data":null}` {
+					t.Fatal("unexpected payload", string(raw))
+				}
+			}
+		}
+	})
+
+	t.Run("should marshal nil slices as empty collections", func(t *testing.T) {
+		var list []*Test
+		var ptr *[]Test
+		for _, input := range []interface{}{list, &list, ptr} {
+			raw, err := Marshal(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(raw) != `{"data":[]}` {
+				t.Fatal("unexpected payload", string(raw))
+			}
+		}
+	})
+}
+
 func TestMarshalWithRelationships(t *testing.T) {
 
 	t.Run("should push correct one-to-one relationships into included list", func(t *testing.T) {
@@ -1036,10 +1073,11 @@ func TestMarshalWithRelationships(t *testing.T) {
 		input := SUT{
 			ID: "1",
 			ByValue: Rel{
-				ID: "",
+				ID:  "",
+				Str: "a",
 			},
 			SliceValue: []Rel{
-				{ID: ""},
+				{ID: "", Str: "b"},
 			},
 		}
 
@@ -1059,6 +1097,119 @@ func TestMarshalWithRelationships(t *testing.T) {
 	})
 }
 
+type attachment interface {
+	isAttachment()
+}
+
+type attachmentImage struct {
+	ID    string `jsonapi:"primary,images"`
+	Width int    `jsonapi:"attr,width"`
+}
+
+func (attachmentImage) isAttachment() {}
+
+type attachmentVideo struct {
+	ID       string `jsonapi:"primary,videos"`
+	Duration int    `jsonapi:"attr,duration"`
+}
+
+func (*attachmentVideo) isAttachment() {}
+
+type attachmentLink string
+
+func (attachmentLink) isAttachment() {}
+
+func TestMarshalInterfaces(t *testing.T) {
+
+	type Post struct {
+		ID          string       `jsonapi:"primary,posts"`
+		Cover       attachment   `jsonapi:"relation,cover"`
+		Attachments []attachment `jsonapi:"relation,attachments"`
+		Related     interface{}  `jsonapi:"relation,related"`
+	}
+
+	marshal := func(t *testing.T, in interface{}) map[string]interface{} {
+		raw, err := Marshal(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check := map[string]interface{}{}
+		if err := json.Unmarshal(raw, &check); err != nil {
+			t.Fatal(err)
+		}
+		return check
+	}
+
+	t.Run("should marshal interface relationships by their concrete types", func(t *testing.T) {
+		check := marshal(t, Post{
+			ID:          "1",
+			Cover:       attachmentImage{ID: "2", Width: 100},
+			Attachments: []attachment{&attachmentImage{ID: "3", Width: 200}, &attachmentVideo{ID: "4", Duration: 60}},
+		})
+
+		rels := check["data"].(map[string]interface{})["relationships"].(map[string]interface{})
+		expected := map[string]interface{}{
+			"cover": map[string]interface{}{"data": map[string]interface{}{"type": "images", "id": "2"}},
+			"attachments": map[string]interface{}{"data": []interface{}{
+				map[string]interface{}{"type": "images", "id": "3"},
+				map[string]interface{}{"type": "videos", "id": "4"},
+			}},
+			"related": map[string]interface{}{"data": nil},
+		}
+		if !reflect.DeepEqual(rels, expected) {
+			t.Errorf("expected %+v, got %+v", expected, rels)
+		}
+
+		if len(check["included"].([]interface{})) != 3 {
+			t.Fatal("unexpected number of included resources", check["included"])
+		}
+	})
+
+	t.Run("should marshal mixed primary data collections", func(t *testing.T) {
+		check := marshal(t, []interface{}{
+			&attachmentImage{ID: "1", Width: 100},
+			attachmentVideo{ID: "1", Duration: 60},
+			&Post{ID: "1", Related: &attachmentImage{ID: "1", Width: 100}},
+		})
+
+		data := check["data"].([]interface{})
+		types := make([]string, len(data))
+		for i, resource := range data {
+			types[i] = resource.(map[string]interface{})["type"].(string)
+		}
+		if !reflect.DeepEqual(types, []string{"images", "videos", "posts"}) {
+			t.Fatal("unexpected primary resource types", types)
+		}
+		if included, _ := check["included"].([]interface{}); len(included) != 0 {
+			t.Fatal("expected related primary resource not to be included", included)
+		}
+	})
+
+	t.Run("should reject nil elements and non-resource values of interface relationships", func(t *testing.T) {
+		cases := map[string]Post{
+			"nil element":         {ID: "1", Attachments: []attachment{&attachmentImage{ID: "2"}, nil}},
+			"nil pointer element": {ID: "1", Attachments: []attachment{(*attachmentVideo)(nil)}},
+			"non-struct element":  {ID: "1", Attachments: []attachment{attachmentLink("x")}},
+			"non-struct value":    {ID: "1", Cover: attachmentLink("x")},
+			"number":              {ID: "1", Related: 42},
+			"string":              {ID: "1", Related: "str"},
+			"struct without key":  {ID: "1", Related: struct{ Name string }{Name: "n"}},
+		}
+
+		for name, input := range cases {
+			if _, err := Marshal(input); err == nil {
+				t.Errorf("expected error for %s", name)
+			}
+		}
+	})
+
+	t.Run("should reject nil elements of primary data collections", func(t *testing.T) {
+		if _, err := Marshal([]interface{}{&attachmentImage{ID: "1"}, nil}); err == nil {
+			t.Fatal("expected nil element error")
+		}
+	})
+}
+
 func TestMarshalRelationshipDeduplication(t *testing.T) {
 	//Inclusion order matters, need to check multiple combinations of the same data in list
 
@@ -1068,7 +1219,7 @@ func TestMarshalRelationshipDeduplication(t *testing.T) {
 			ID      string `jsonapi:"primary,comments"`
 			Content string
 			Bool    bool
-			ReplyTo *Comment `jsonapi:"relation,reply"`
+			ReplyTo *Comment `jsonapi:"relation,reply,include"`
 		}
 
 		type Post struct {
@@ -1148,7 +1299,7 @@ func TestMarshalRelationshipDeduplication(t *testing.T) {
 
 		type Comment struct {
 			ID     string   `jsonapi:"primary,comments"`
-			Parent *Comment `jsonapi:"relation,parent"`
+			Parent *Comment `jsonapi:"relation,parent,include"`
 		}
 
 		type Post struct {
@@ -1246,6 +1397,99 @@ func TestMarshalRelationshipDeduplication(t *testing.T) {
 			t.Fatal("erased relationship value")
 		}
 	})
+
+	t.Run("should resolve conflicting values according to the merge mode", func(t *testing.T) {
+
+		type Person struct {
+			ID   string `jsonapi:"primary,people"`
+			Name string `jsonapi:"attr,name"`
+			Age  int    `jsonapi:"attr,age"`
+		}
+
+		type Post struct {
+			ID     string    `jsonapi:"primary,posts"`
+			Author *Person   `jsonapi:"relation,author"`
+			Likes  []*Person `jsonapi:"relation,likes"`
+		}
+
+		input := Post{
+			ID:     "1",
+			Author: &Person{ID: "2", Name: "second"},
+			Likes:  []*Person{{ID: "2", Name: "SECOND", Age: 30}},
+		}
+
+		nameOf := func(t *testing.T, opts ...Option) interface{} {
+			raw, err := Marshal(input, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			check := map[string]interface{}{}
+			if err := json.Unmarshal(raw, &check); err != nil {
+				t.Fatal(err)
+			}
+			included := check["included"].([]interface{})
+			if len(included) != 1 {
+				t.Fatalf("unexpected number of included resources, got %v", len(included))
+			}
+			attributes := included[0].(map[string]interface{})["attributes"].(map[string]interface{})
+			if attributes["age"] != float64(30) {
+				t.Fatal("expected zero value to be filled in", attributes["age"])
+			}
+			return attributes["name"]
+		}
+
+		if name := nameOf(t); name != "SECOND" {
+			t.Fatal("expected last value by default", name)
+		}
+		if name := nameOf(t, WithIncludedMerge(IncludedMergeLastWins)); name != "SECOND" {
+			t.Fatal("expected last value", name)
+		}
+		if name := nameOf(t, WithIncludedMerge(IncludedMergeFirstWins)); name != "second" {
+			t.Fatal("expected first value", name)
+		}
+
+		_, err := Marshal(input, WithIncludedMerge(IncludedMergeStrict))
+		conflict := &IncludedConflictError{}
+		if !errors.As(err, &conflict) {
+			t.Fatal("expected conflict error", err)
+		}
+		if conflict.Type != "people" || conflict.ID != "2" || conflict.Kind != "attribute" || conflict.Member != "name" {
+			t.Fatal("unexpected conflict", conflict)
+		}
+
+		input.Likes[0].Name = "second"
+		if _, err := Marshal(input, WithIncludedMerge(IncludedMergeStrict)); err != nil {
+			t.Fatal("expected agreeing views to merge", err)
+		}
+	})
+
+	t.Run("should not mix up resources with colliding type and id concatenation", func(t *testing.T) {
+
+		type A struct {
+			ID string `jsonapi:"primary,ab"`
+		}
+		type B struct {
+			ID string `jsonapi:"primary,a"`
+		}
+		type SUT struct {
+			ID string `jsonapi:"primary,mains"`
+			A  *A     `jsonapi:"relation,a,include"`
+			B  *B     `jsonapi:"relation,b,include"`
+		}
+
+		raw, err := Marshal(SUT{ID: "1", A: &A{ID: "c"}, B: &B{ID: "bc"}}, WithIncludedMerge(IncludedMergeStrict))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		check := map[string]interface{}{}
+		if err := json.Unmarshal(raw, &check); err != nil {
+			t.Fatal(err)
+		}
+		if len(check["included"].([]interface{})) != 2 {
+			t.Fatalf("unexpected number of included resources, got %v", len(check["included"].([]interface{})))
+		}
+	})
 }
 
 func TestMarshalID(t *testing.T) {
@@ -1323,6 +1567,102 @@ func TestMarshalID(t *testing.T) {
 			t.Fatal("unexpected id")
 		}
 	})
+
+	t.Run("should marshal pointer ids with pointer receiver text marshalers", func(t *testing.T) {
+		type SUT struct {
+			ID *PointerTextID `jsonapi:"primary,tests"`
+		}
+		type Value struct {
+			ID PointerTextID `jsonapi:"primary,tests"`
+		}
+
+		for _, input := range []interface{}{SUT{ID: &PointerTextID{value: "abc"}}, &Value{ID: PointerTextID{value: "abc"}}} {
+			raw, err := Marshal(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			check := map[string]interface{}{}
+			if err := json.Unmarshal(raw, &check); err != nil {
+				t.Fatal(err)
+			}
+
+			if check["data"].(map[string]interface{})["id"] != "abc" {
+				t.Fatal("unexpected id", string(raw))
+			}
+		}
+	})
+
+	t.Run("should marshal byte array ids as hex", func(t *testing.T) {
+		type SUT struct {
+			UUID [16]byte `jsonapi:"primary,tests"`
+		}
+
+		raw, err := Marshal(SUT{UUID: [16]byte{0x01, 0x02, 0xab, 15: 0xff}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		check := map[string]interface{}{}
+		if err := json.Unmarshal(raw, &check); err != nil {
+			t.Fatal(err)
+		}
+
+		if check["data"].(map[string]interface{})["id"] != "0102ab000000000000000000000000ff" {
+			t.Fatal("unexpected id", string(raw))
+		}
+	})
+
+	t.Run("should use the tagged primary field regardless of its name", func(t *testing.T) {
+		type Base struct {
+			UUID StringSerializable `jsonapi:"primary,bases"`
+		}
+
+		type Named struct {
+			Key string `jsonapi:"primary,users"`
+			ID  string `jsonapi:"attr,legacyId"`
+		}
+		type Binary struct {
+			UUID StringSerializable `jsonapi:"primary,binaries"`
+		}
+		type Pointer struct {
+			Ref *string `jsonapi:"primary,pointers"`
+		}
+		type Embedded struct {
+			*Base
+		}
+
+		ref := "ref"
+		cases := []struct {
+			input        interface{}
+			resourceType string
+			id           string
+		}{
+			{Named{Key: "key", ID: "legacy"}, "users", "key"},
+			{Binary{UUID: StringSerializable{1, 2, 3, 4}}, "binaries", "01020304"},
+			{Pointer{Ref: &ref}, "pointers", "ref"},
+			{Pointer{}, "pointers", ""},
+			{Embedded{Base: &Base{UUID: StringSerializable{0xa, 0xb, 0xc, 0xd}}}, "bases", "0a0b0c0d"},
+			{Embedded{}, "bases", ""},
+		}
+
+		for _, c := range cases {
+			raw, err := Marshal(c.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			check := map[string]interface{}{}
+			if err := json.Unmarshal(raw, &check); err != nil {
+				t.Fatal(err)
+			}
+
+			data := check["data"].(map[string]interface{})
+			if data["type"] != c.resourceType || data["id"] != c.id {
+				t.Errorf("expected %s/%s, got %s/%s", c.resourceType, c.id, data["type"], data["id"])
+			}
+		}
+	})
 }
 
 func TestMarshalTime(t *testing.T) {
@@ -1368,6 +1708,75 @@ func TestMarshalTime(t *testing.T) {
 		}
 
 	})
+
+	t.Run("should apply time format tag options and per call default", func(t *testing.T) {
+		type Nested struct {
+			At     time.Time `json:"at"`
+			AtUnix time.Time `jsonapi:"attr,atUnix,unix"`
+		}
+
+		type SUT struct {
+			ID        string      `jsonapi:"primary,tests"`
+			Default   time.Time   `jsonapi:"attr,default"`
+			Unix      time.Time   `jsonapi:"attr,unix,unix"`
+			UnixMilli *time.Time  `jsonapi:"attr,unixMilli,unixmilli"`
+			Nano      time.Time   `jsonapi:"attr,nano,rfc3339nano"`
+			Date      time.Time   `jsonapi:"attr,date,date,omitempty"`
+			List      []time.Time `jsonapi:"attr,list,unix"`
+			Nested    Nested      `jsonapi:"attr,nested"`
+			NestedMs  *Nested     `jsonapi:"attr,nestedMs,unixmilli"`
+		}
+
+		moment := time.Date(2018, 1, 2, 3, 4, 5, 123456789, time.UTC)
+		input := SUT{
+			ID:        "1",
+			Default:   moment,
+			Unix:      moment,
+			UnixMilli: &moment,
+			Nano:      moment,
+			Date:      moment,
+			List:      []time.Time{moment},
+			Nested:    Nested{At: moment, AtUnix: moment},
+			NestedMs:  &Nested{At: moment, AtUnix: moment},
+		}
+
+		for _, c := range []struct {
+			opts     []Option
+			expected string
+		}{
+			{nil, "2018-01-02T03:04:05Z"},
+			{[]Option{WithTimeFormat("rfc3339nano")}, "2018-01-02T03:04:05.123456789Z"},
+			{[]Option{WithTimeFormat(time.Kitchen)}, "3:04AM"},
+		} {
+			raw, err := Marshal(input, c.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			check := map[string]interface{}{}
+			if err := json.Unmarshal(raw, &check); err != nil {
+				t.Fatal(err)
+			}
+
+			attrs := check["data"].(map[string]interface{})["attributes"].(map[string]interface{})
+			expected := map[string]interface{}{
+				"default":   c.expected,
+				"unix":      float64(moment.Unix()),
+				"unixMilli": float64(moment.UnixMilli()),
+				"nano":      "2018-01-02T03:04:05.123456789Z",
+				"date":      "2018-01-02",
+				"list":      []interface{}{float64(moment.Unix())},
+				"nested":    map[string]interface{}{"at": c.expected, "atUnix": float64(moment.Unix())},
+				"nestedMs":  map[string]interface{}{"at": float64(moment.UnixMilli()), "atUnix": float64(moment.Unix())},
+			}
+
+			for k, v := range expected {
+				if !reflect.DeepEqual(attrs[k], v) {
+					t.Errorf("expected %s to be %v, got %v", k, v, attrs[k])
+				}
+			}
+		}
+	})
 }
 
 func TestMarshalRecursive(t *testing.T) {
@@ -1392,7 +1801,75 @@ func TestMarshalRecursive(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		if len(check["included"].([]interface{})) != 2 {
+		//The primary resource is not repeated in included
+		if len(check["included"].([]interface{})) != 1 {
+			t.Fatal("unexpected number of included resources")
+		}
+	})
+
+	t.Run("should keep primary resources out of included", func(t *testing.T) {
+		type Post struct {
+			ID      string `jsonapi:"primary,posts"`
+			Title   string `jsonapi:"attr,title"`
+			Summary string `jsonapi:"attr,summary"`
+			Related *Post  `jsonapi:"relation,related"`
+		}
+
+		first := &Post{ID: "1", Title: "first"}
+		third := &Post{ID: "3", Title: "third"}
+		input := []*Post{
+			first,
+			{ID: "2", Title: "second", Related: &Post{ID: "1", Title: "stale", Summary: "about first"}},
+			{ID: "4", Title: "fourth", Related: third},
+		}
+
+		raw, err := Marshal(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		check := map[string]interface{}{}
+		if err := json.Unmarshal(raw, &check); err != nil {
+			t.Fatal(err)
+		}
+
+		included := check["included"].([]interface{})
+		if len(included) != 1 || included[0].(map[string]interface{})["id"] != "3" {
+			t.Fatal("unexpected included resources", included)
+		}
+
+		attrs := check["data"].([]interface{})[0].(map[string]interface{})["attributes"].(map[string]interface{})
+		if attrs["title"] != "first" || attrs["summary"] != "about first" {
+			t.Fatal("expected included view to fill in primary zero values", attrs)
+		}
+
+		if _, err := Marshal(input, WithIncludedMerge(IncludedMergeStrict)); err == nil {
+			t.Fatal("expected conflict with primary resource")
+		}
+	})
+
+	t.Run("should tell apart resources sharing an address", func(t *testing.T) {
+		type Person struct {
+			ID   string `jsonapi:"primary,people"`
+			Name string `jsonapi:"attr,name"`
+		}
+		//Author is the first field, so the post and its author share the address
+		type Post struct {
+			Author Person `jsonapi:"relation,author"`
+			ID     string `jsonapi:"primary,posts"`
+		}
+
+		raw, err := Marshal(&Post{ID: "1", Author: Person{ID: "1", Name: "a"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		check := map[string]interface{}{}
+		if err := json.Unmarshal(raw, &check); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(check["included"].([]interface{})) != 1 {
 			t.Fatal("unexpected number of included resources")
 		}
 	})
@@ -1417,7 +1894,8 @@ func TestMarshalRecursive(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		if len(check["included"].([]interface{})) != 2 {
+		//The primary resource is not repeated in included
+		if len(check["included"].([]interface{})) != 1 {
 			t.Fatal("unexpected number of included resources")
 		}
 	})
@@ -1635,3 +2113,140 @@ func Test_isRelationshipZero(t *testing.T) {
 		}
 	})
 }
+
+type typedPerson struct {
+	ID   string `jsonapi:"primary"`
+	Name string `jsonapi:"attr,name"`
+}
+
+func (typedPerson) JSONAPIType() string { return "people" }
+
+type typedEnvelope[T any] struct {
+	ID    string `jsonapi:"primary"`
+	Value T      `jsonapi:"attr,value"`
+}
+
+func (*typedEnvelope[T]) JSONAPIType() string {
+	return "envelopes-" + reflect.TypeFor[T]().Name()
+}
+
+func TestResourceTyper(t *testing.T) {
+
+	type Article struct {
+		ID      string                 `jsonapi:"primary"`
+		Author  *typedPerson           `jsonapi:"relation,author"`
+		Payload *typedEnvelope[string] `jsonapi:"relation,payload"`
+	}
+
+	t.Run("should take resource types from the method on primary and related resources", func(t *testing.T) {
+		type TaggedArticle struct {
+			ID     string       `jsonapi:"primary,articles"`
+			Author *typedPerson `jsonapi:"relation,author"`
+			Editor typedPerson  `jsonapi:"relation,editor"`
+		}
+
+		raw, err := MarshalOne(&TaggedArticle{
+			ID:     "1",
+			Author: &typedPerson{ID: "2", Name: "a"},
+			Editor: typedPerson{ID: "3", Name: "e"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out := map[string]interface{}{}
+		if err := json.Unmarshal(raw, &out); err != nil {
+			t.Fatal(err)
+		}
+
+		relationships := out["data"].(map[string]interface{})["relationships"].(map[string]interface{})
+		for _, name := range []string{"author", "editor"} {
+			linkage := relationships[name].(map[string]interface{})["data"].(map[string]interface{})
+			if linkage["type"] != "people" {
+				t.Errorf("expected %s linkage type people, got %v", name, linkage["type"])
+			}
+		}
+		for _, included := range out["included"].([]interface{}) {
+			if included.(map[string]interface{})["type"] != "people" {
+				t.Errorf("expected included people, got %v", included)
+			}
+		}
+
+		raw, err = MarshalOne(&typedEnvelope[int]{ID: "4", Value: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(raw, &out); err != nil {
+			t.Fatal(err)
+		}
+		if out["data"].(map[string]interface{})["type"] != "envelopes-int" {
+			t.Fatal("expected type from the method", string(raw))
+		}
+	})
+
+	t.Run("should fail without a type in the tag or the method", func(t *testing.T) {
+		_, err := MarshalOne(&Article{ID: "1"})
+		if err == nil || !errors.Is(err, errNoResourceType) {
+			t.Fatal("expected missing resource type error", err)
+		}
+	})
+
+	t.Run("should check unmarshaled types against the method", func(t *testing.T) {
+		raw := []byte(`{
+			"data":{"type":"envelopes-string","id":"1","attributes":{"value":"v"}},
+			"included":[]
+		}`)
+
+		out := typedEnvelope[string]{}
+		if err := Unmarshal(raw, &out); err != nil {
+			t.Fatal(err)
+		}
+		if out.ID != "1" || out.Value != "v" {
+			t.Fatal("unexpected model", out)
+		}
+
+		if err := Unmarshal(raw, &typedEnvelope[int]{}); err == nil {
+			t.Fatal("expected type mismatch error")
+		}
+	})
+
+	t.Run("should check related types against the method", func(t *testing.T) {
+		type Post struct {
+			ID     string       `jsonapi:"primary,posts"`
+			Author *typedPerson `jsonapi:"relation,author"`
+		}
+
+		out := Post{}
+		err := Unmarshal([]byte(`{"data":{"type":"posts","id":"1","relationships":{"author":{"data":{"type":"people","id":"2"}}}}}`), &out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out.Author == nil || out.Author.ID != "2" {
+			t.Fatal("unexpected author", out.Author)
+		}
+
+		err = Unmarshal([]byte(`{"data":{"type":"posts","id":"1","relationships":{"author":{"data":{"type":"users","id":"2"}}}}}`), &Post{})
+		if err == nil {
+			t.Fatal("expected related type mismatch error")
+		}
+	})
+
+	t.Run("should validate patches on relations to typed resources", func(t *testing.T) {
+		type Post struct {
+			ID      string                 `jsonapi:"primary,posts"`
+			Author  *typedPerson           `jsonapi:"relation,author"`
+			Payload *typedEnvelope[string] `jsonapi:"relation,payload"`
+		}
+
+		parsed, err := UnmarshalPatches([]byte(`[
+			{"op":"replace","path":"/author","value":"2"},
+			{"op":"replace","path":"/payload","value":"3"}
+		]`), reflect.TypeOf(new(Post)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed[0].Value != "2" || parsed[1].Value != "3" {
+			t.Fatal("unexpected patch values", parsed)
+		}
+	})
+}
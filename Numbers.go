@@ -0,0 +1,212 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+	"strconv"
+)
+
+var (
+	ErrNumberOutOfRange = errors.New("number out of range")
+	ErrFractionalNumber = errors.New("fractional number for an integer")
+)
+
+// NumberError reports a JSON number that cannot be stored in a numeric field without loss.
+type NumberError struct {
+	Value string
+	Kind  reflect.Kind
+	Err   error
+}
+
+func (e *NumberError) Error() string {
+	return fmt.Sprintf("cannot unmarshal %s into %s: %s", e.Value, e.Kind, e.Err)
+}
+
+func (e *NumberError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	jsonNumberType = reflect.TypeOf(json.Number(""))
+	bigFloatType   = reflect.TypeOf(big.Float{})
+)
+
+var numberKindTypes = map[reflect.Kind]reflect.Type{
+	reflect.Int:     reflect.TypeOf(int(0)),
+	reflect.Int8:    reflect.TypeOf(int8(0)),
+	reflect.Int16:   reflect.TypeOf(int16(0)),
+	reflect.Int32:   reflect.TypeOf(int32(0)),
+	reflect.Int64:   reflect.TypeOf(int64(0)),
+	reflect.Uint:    reflect.TypeOf(uint(0)),
+	reflect.Uint8:   reflect.TypeOf(uint8(0)),
+	reflect.Uint16:  reflect.TypeOf(uint16(0)),
+	reflect.Uint32:  reflect.TypeOf(uint32(0)),
+	reflect.Uint64:  reflect.TypeOf(uint64(0)),
+	reflect.Float32: reflect.TypeOf(float32(0)),
+	reflect.Float64: reflect.TypeOf(float64(0)),
+}
+
+// decodeJSON works as json.Unmarshal but keeps numbers as json.Number so that they can be converted without loss.
+func decodeJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return errors.New("invalid character after top-level value")
+	}
+	return nil
+}
+
+// numberText returns the literal of a decoded JSON number. float64 is accepted for values built outside of decodeJSON.
+func numberText(attribute interface{}) (string, bool) {
+	switch n := attribute.(type) {
+	case json.Number:
+		return n.String(), true
+	case float64:
+		return strconv.FormatFloat(n, 'g', -1, 64), true
+	default:
+		return "", false
+	}
+}
+
+// parseNumber converts a JSON number into a value of the basic type of the numeric kind.
+// Integers are rejected when fractional or out of range, floats when out of range.
+func parseNumber(kind reflect.Kind, attribute interface{}) (reflect.Value, error) {
+	text, ok := numberText(attribute)
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("expected a number for %s, got %T", kind, attribute)
+	}
+
+	v := reflect.New(numberKindTypes[kind]).Elem()
+	bits := v.Type().Bits()
+
+	switch kind {
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(text, bits)
+		if err != nil {
+			if errors.Is(err, strconv.ErrRange) {
+				return reflect.Value{}, &NumberError{Value: text, Kind: kind, Err: ErrNumberOutOfRange}
+			}
+			return reflect.Value{}, &NumberError{Value: text, Kind: kind, Err: err}
+		}
+		v.SetFloat(f)
+		return v, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i, err := strconv.ParseInt(text, 10, bits); err == nil {
+			v.SetInt(i)
+			return v, nil
+		}
+	default:
+		if u, err := strconv.ParseUint(text, 10, bits); err == nil {
+			v.SetUint(u)
+			return v, nil
+		}
+	}
+
+	//Exponent notation, fractions and out of range values end up here
+	r, ok := new(big.Rat).SetString(text)
+	if !ok {
+		return reflect.Value{}, &NumberError{Value: text, Kind: kind, Err: strconv.ErrSyntax}
+	}
+	if !r.IsInt() {
+		return reflect.Value{}, &NumberError{Value: text, Kind: kind, Err: ErrFractionalNumber}
+	}
+
+	n := r.Num()
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if !n.IsInt64() || v.OverflowInt(n.Int64()) {
+			return reflect.Value{}, &NumberError{Value: text, Kind: kind, Err: ErrNumberOutOfRange}
+		}
+		v.SetInt(n.Int64())
+	default:
+		if !n.IsUint64() || v.OverflowUint(n.Uint64()) {
+			return reflect.Value{}, &NumberError{Value: text, Kind: kind, Err: ErrNumberOutOfRange}
+		}
+		v.SetUint(n.Uint64())
+	}
+	return v, nil
+}
+
+func isNumberKind(kind reflect.Kind) bool {
+	_, ok := numberKindTypes[kind]
+	return ok
+}
+
+// normalizeNumbers converts json.Number back to float64 for values stored into interface{} to keep them
+// in the same shape as json.Unmarshal produces. The input is not modified.
+func normalizeNumbers(attribute interface{}) interface{} {
+	switch v := attribute.(type) {
+	case json.Number:
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			out[key] = normalizeNumbers(value)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, value := range v {
+			out[i] = normalizeNumbers(value)
+		}
+		return out
+	default:
+		return attribute
+	}
+}
+
+// encodeBigFloat writes big.Float as a JSON number instead of the quoted text encoding/json would produce.
+func encodeBigFloat(field reflect.Value) (interface{}, bool) {
+	if field.Type() != bigFloatType {
+		return nil, false
+	}
+	f := field.Interface().(big.Float)
+	return json.Number(f.Text('g', -1)), true
+}
+
+// unmarshalBigFloat reads big.Float with enough precision to keep all digits of the decimal literal.
+func unmarshalBigFloat(fieldVal reflect.Value, attribute interface{}) (bool, error) {
+	isPointer := fieldVal.Kind() == reflect.Pointer
+	if fieldVal.Type() != bigFloatType && !(isPointer && fieldVal.Type().Elem() == bigFloatType) {
+		return false, nil
+	}
+
+	if attribute == nil {
+		if isPointer {
+			fieldVal.Set(reflect.Zero(fieldVal.Type()))
+		}
+		return true, nil
+	}
+
+	text, ok := numberText(attribute)
+	if !ok {
+		if text, ok = attribute.(string); !ok {
+			return true, fmt.Errorf("expected a number for big.Float, got %T", attribute)
+		}
+	}
+
+	prec := uint(len(text)) * 4
+	if prec < 64 {
+		prec = 64
+	}
+	f, _, err := big.ParseFloat(text, 10, prec, big.ToNearestEven)
+	if err != nil {
+		return true, err
+	}
+
+	if isPointer {
+		fieldVal.Set(reflect.ValueOf(f))
+	} else {
+		fieldVal.Set(reflect.ValueOf(f).Elem())
+	}
+	return true, nil
+}
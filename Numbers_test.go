@@ -0,0 +1,238 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLosslessNumbers(t *testing.T) {
+
+	type SUT struct {
+		ID      string                 `jsonapi:"primary,tests"`
+		Int64   int64                  `jsonapi:"attr,int64"`
+		Uint64  uint64                 `jsonapi:"attr,uint64"`
+		PInt64  *int64                 `jsonapi:"attr,pInt64"`
+		Int64s  []int64                `jsonapi:"attr,int64s"`
+		Int8    int8                   `jsonapi:"attr,int8"`
+		Float32 float32                `jsonapi:"attr,float32"`
+		Number  json.Number            `jsonapi:"attr,number"`
+		PNumber *json.Number           `jsonapi:"attr,pNumber"`
+		BigInt  big.Int                `jsonapi:"attr,bigInt"`
+		PBigInt *big.Int               `jsonapi:"attr,pBigInt"`
+		BigFlt  big.Float              `jsonapi:"attr,bigFloat"`
+		PBigFlt *big.Float             `jsonapi:"attr,pBigFloat"`
+		Any     interface{}            `jsonapi:"attr,any"`
+		AnyMap  map[string]interface{} `jsonapi:"attr,anyMap"`
+	}
+
+	t.Run("should keep 64 bit integers exact", func(t *testing.T) {
+		raw := []byte(`{"data":{"id":"1","type":"tests","attributes":{
+			"int64":9007199254740993,"uint64":18446744073709551615,"pInt64":-9223372036854775808,
+			"int64s":[9007199254740993,1e3]}}}`)
+
+		out := SUT{}
+		if err := Unmarshal(raw, &out); err != nil {
+			t.Fatal(err)
+		}
+
+		if out.Int64 != 9007199254740993 {
+			t.Fatal("unexpected int64 value", out.Int64)
+		}
+		if out.Uint64 != math.MaxUint64 {
+			t.Fatal("unexpected uint64 value", out.Uint64)
+		}
+		if out.PInt64 == nil || *out.PInt64 != math.MinInt64 {
+			t.Fatal("unexpected int64 pointer value", out.PInt64)
+		}
+		if !reflect.DeepEqual(out.Int64s, []int64{9007199254740993, 1000}) {
+			t.Fatal("unexpected int64 slice value", out.Int64s)
+		}
+	})
+
+	t.Run("should reject fractional values for integers", func(t *testing.T) {
+		raw := []byte(`{"data":{"id":"1","type":"tests","attributes":{"int64":1.5}}}`)
+
+		err := Unmarshal(raw, &SUT{})
+		var numberErr *NumberError
+		if !errors.As(err, &numberErr) || !errors.Is(err, ErrFractionalNumber) {
+			t.Fatal("expected fractional number error, got", err)
+		}
+		if numberErr.Value != "1.5" || numberErr.Kind != reflect.Int64 {
+			t.Fatal("unexpected error details", numberErr)
+		}
+	})
+
+	t.Run("should reject out of range values", func(t *testing.T) {
+		for _, attributes := range []string{
+			`{"int8":128}`,
+			`{"uint64":-1}`,
+			`{"int64":9223372036854775808}`,
+			`{"int64s":[1e19]}`,
+			`{"float32":1e39}`,
+		} {
+			raw := []byte(`{"data":{"id":"1","type":"tests","attributes":` + attributes + `}}`)
+			if err := Unmarshal(raw, &SUT{}); !errors.Is(err, ErrNumberOutOfRange) {
+				t.Fatal("expected out of range error for", attributes, "got", err)
+			}
+		}
+	})
+
+	t.Run("should round trip json.Number, big.Int and big.Float", func(t *testing.T) {
+		bigInt, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+		bigFloat, _, _ := big.ParseFloat("1234567890.123456789012345678901", 10, 200, big.ToNearestEven)
+		number := json.Number("12345678901234567890.5")
+
+		input := SUT{
+			ID:      "1",
+			Number:  "98765432109876543210",
+			PNumber: &number,
+			BigInt:  *bigInt,
+			PBigInt: bigInt,
+			BigFlt:  *bigFloat,
+			PBigFlt: bigFloat,
+		}
+
+		raw, err := Marshal(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		check := map[string]interface{}{}
+		if err := decodeJSON(raw, &check); err != nil {
+			t.Fatal(err)
+		}
+		attrs := check["data"].(map[string]interface{})["attributes"].(map[string]interface{})
+		if attrs["bigInt"] != json.Number("123456789012345678901234567890") {
+			t.Fatal("expected big.Int to be encoded as a number", attrs["bigInt"])
+		}
+		if attrs["pBigFloat"] != json.Number(bigFloat.Text('g', -1)) {
+			t.Fatal("expected big.Float to be encoded as a number", attrs["pBigFloat"])
+		}
+
+		out := SUT{}
+		if err := Unmarshal(raw, &out); err != nil {
+			t.Fatal(err)
+		}
+
+		if out.Number != "98765432109876543210" || out.PNumber == nil || *out.PNumber != number {
+			t.Fatal("unexpected json.Number values", out.Number, out.PNumber)
+		}
+		if out.BigInt.Cmp(bigInt) != 0 || out.PBigInt == nil || out.PBigInt.Cmp(bigInt) != 0 {
+			t.Fatal("unexpected big.Int values", out.BigInt.String(), out.PBigInt)
+		}
+		expectedFloat := bigFloat.Text('g', -1)
+		if out.BigFlt.Text('g', -1) != expectedFloat || out.PBigFlt == nil || out.PBigFlt.Text('g', -1) != expectedFloat {
+			t.Fatal("unexpected big.Float values", out.BigFlt.String(), out.PBigFlt)
+		}
+	})
+
+	t.Run("should keep float64 in interface values", func(t *testing.T) {
+		raw := []byte(`{"data":{"id":"1","type":"tests","attributes":{"any":1,"anyMap":{"a":[2],"b":{"c":3}}}}}`)
+
+		out := SUT{}
+		if err := Unmarshal(raw, &out); err != nil {
+			t.Fatal(err)
+		}
+
+		if out.Any != float64(1) {
+			t.Fatal("unexpected interface value", out.Any)
+		}
+		expected := map[string]interface{}{"a": []interface{}{float64(2)}, "b": map[string]interface{}{"c": float64(3)}}
+		if !reflect.DeepEqual(out.AnyMap, expected) {
+			t.Fatal("unexpected interface map value", out.AnyMap)
+		}
+	})
+
+	t.Run("should round trip 64 bit integer ids", func(t *testing.T) {
+		type WithID struct {
+			ID int64 `jsonapi:"primary,tests"`
+		}
+		type WithUintID struct {
+			ID uint64 `jsonapi:"primary,tests"`
+		}
+
+		raw, err := Marshal(WithID{ID: math.MaxInt64})
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := WithID{}
+		if err := Unmarshal(raw, &out); err != nil {
+			t.Fatal(err)
+		}
+		if out.ID != math.MaxInt64 {
+			t.Fatal("unexpected id", out.ID)
+		}
+
+		raw, err = Marshal(WithUintID{ID: math.MaxUint64})
+		if err != nil {
+			t.Fatal(err)
+		}
+		outUint := WithUintID{}
+		if err := Unmarshal(raw, &outUint); err != nil {
+			t.Fatal(err)
+		}
+		if outUint.ID != math.MaxUint64 {
+			t.Fatal("unexpected id", outUint.ID)
+		}
+
+		numeric := WithID{}
+		if err := Unmarshal([]byte(`{"data":{"id":9007199254740993,"type":"tests"}}`), &numeric); err != nil {
+			t.Fatal(err)
+		}
+		if numeric.ID != 9007199254740993 {
+			t.Fatal("unexpected id from a number", numeric.ID)
+		}
+
+		if err := Unmarshal([]byte(`{"data":{"id":1.5,"type":"tests"}}`), &WithID{}); !errors.Is(err, ErrFractionalNumber) {
+			t.Fatal("expected fractional number error, got", err)
+		}
+	})
+
+	t.Run("should keep numbers exact when mixing in meta", func(t *testing.T) {
+		raw, err := MarshalOne(&SUT{ID: "1", Int64: 9007199254740993})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		raw, err = MixInMeta(raw, map[string]interface{}{"count": 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(raw), `"int64":9007199254740993`) {
+			t.Fatal("expected exact attribute", string(raw))
+		}
+	})
+
+	t.Run("should keep patch values exact", func(t *testing.T) {
+		patches, err := UnmarshalPatches([]byte(`[{"op":"replace","path":"/int64","value":9007199254740993}]`), reflect.TypeOf(&SUT{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if patches[0].Value != int64(9007199254740993) {
+			t.Fatal("unexpected patch value", patches[0].Value)
+		}
+	})
+
+	t.Run("should keep filter values exact", func(t *testing.T) {
+		conditions, err := ParseFilter(url.Values{"filter[int64]": {"9007199254740993"}}, reflect.TypeOf(SUT{}), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value := conditions.Expressions[0].(FilterCondition).Value; value != int64(9007199254740993) {
+			t.Fatal("unexpected filter value", value)
+		}
+
+		if _, err := ParseFilter(url.Values{"filter[int64]": {"1.5"}}, reflect.TypeOf(SUT{}), nil); err == nil {
+			t.Fatal("expected error for fractional filter value")
+		}
+		if _, err := ParseFilter(url.Values{"filter[int64]": {"0x10"}}, reflect.TypeOf(SUT{}), nil); err == nil {
+			t.Fatal("expected error for non JSON number")
+		}
+	})
+}
@@ -0,0 +1,105 @@
+package jsonapi
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Option adjusts the behaviour of a single Marshal / Unmarshal call.
+type Option func(*options)
+
+type options struct {
+	//timeFormat is either a time layout or one of the unix formats
+	timeFormat string
+	//codecs registered for the call only, see WithCodec
+	codecs map[reflect.Type]codec
+	//includedMerge resolves conflicting views of the same included resource, see WithIncludedMerge
+	includedMerge IncludedMerge
+	//requestDocument shapes the output for requests creating or updating resources, see WithRequestDocument
+	requestDocument bool
+	//sideposting keeps included resources in request documents
+	sideposting bool
+	//typeMap maps resource types to model struct types for interface typed targets, see WithTypeMap
+	typeMap map[string]reflect.Type
+}
+
+const (
+	timeFormatUnix      = "unix"
+	timeFormatUnixMilli = "unixmilli"
+)
+
+// timeFormats maps the time format names accepted in struct tags to the layouts they stand for.
+var timeFormats = map[string]string{
+	"unix":        timeFormatUnix,
+	"unixmilli":   timeFormatUnixMilli,
+	"rfc3339":     time.RFC3339,
+	"rfc3339nano": time.RFC3339Nano,
+	"date":        time.DateOnly,
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		timeFormat: time.RFC3339,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithTimeFormat sets the default encoding of time.Time attributes for the call.
+// It accepts the same names as the attr tag options (unix, unixmilli, rfc3339, rfc3339nano, date) or any time layout.
+// Attributes tagged with their own format keep it.
+func WithTimeFormat(format string) Option {
+	return func(o *options) {
+		if named, ok := timeFormats[format]; ok {
+			o.timeFormat = named
+		} else {
+			o.timeFormat = format
+		}
+	}
+}
+
+// forField applies attribute specific tag options on top of the call options. The result is used for the whole
+// attribute value, including slice elements and nested structs, unless a nested field overrides it again.
+func (o *options) forField(field reflect.StructField) *options {
+	jsonapiTag := field.Tag.Get("jsonapi")
+	if jsonapiTag == "" {
+		return o
+	}
+
+	parts := strings.Split(jsonapiTag, ",")
+	if parts[0] != "attr" || len(parts) < 3 {
+		return o
+	}
+
+	for _, option := range parts[2:] {
+		if format, ok := timeFormats[option]; ok && format != o.timeFormat {
+			next := *o
+			next.timeFormat = format
+			return &next
+		}
+	}
+	return o
+}
+
+// IncludedMerge decides how views of the same included resource that hold different non-zero values are merged.
+type IncludedMerge int
+
+const (
+	//IncludedMergeLastWins keeps the value of the view emitted last
+	IncludedMergeLastWins IncludedMerge = iota
+	//IncludedMergeFirstWins keeps the value of the view emitted first
+	IncludedMergeFirstWins
+	//IncludedMergeStrict fails with IncludedConflictError
+	IncludedMergeStrict
+)
+
+// WithIncludedMerge sets how conflicting views of included resources are merged, IncludedMergeLastWins by default.
+// Zero values never conflict, they are filled in from the other views.
+func WithIncludedMerge(mode IncludedMerge) Option {
+	return func(o *options) {
+		o.includedMerge = mode
+	}
+}
@@ -0,0 +1,244 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const CursorPaginationProfile = "https://jsonapi.org/profiles/ethanresnick/cursor-pagination"
+
+// Pagination produces top-level pagination links and the content of meta.page for a collection document.
+type Pagination interface {
+	//PaginationLinks builds first, prev, next and last links from the request URL. Unavailable links are nil
+	PaginationLinks(requestURL *url.URL) map[string]interface{}
+	//PaginationMeta returns the members of meta.page
+	PaginationMeta() map[string]interface{}
+}
+
+// PageNumberPagination implements page[number] and page[size] strategy. Pages are numbered from 1.
+type PageNumberPagination struct {
+	Number int
+	Size   int
+	//Total is the total number of resources in the collection
+	Total int
+}
+
+// OffsetPagination implements page[offset] and page[limit] strategy.
+type OffsetPagination struct {
+	Offset int
+	Limit  int
+	//Total is the total number of resources in the collection
+	Total int
+}
+
+// CursorPagination implements the cursor pagination profile with page[size], page[after] and page[before].
+type CursorPagination struct {
+	Size int
+	//After and Before hold the cursors received with the request
+	After  string
+	Before string
+	//StartCursor and EndCursor are the cursors of the first and the last resource of the returned page
+	StartCursor string
+	EndCursor   string
+	HasPrev     bool
+	HasNext     bool
+	//RangeTruncated reports that the page was cut at Size while page[after] and page[before] were both provided
+	RangeTruncated bool
+	//EstimatedTotal is emitted in meta.page when positive
+	EstimatedTotal int
+}
+
+func NewPageNumberPagination(q *Query, defaultSize int, maxSize int) (*PageNumberPagination, error) {
+	number, err := parsePageMember(q, "number", 1, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+	size, err := parsePageMember(q, "size", defaultSize, 1, maxSize)
+	if err != nil {
+		return nil, err
+	}
+	return &PageNumberPagination{Number: number, Size: size}, nil
+}
+
+func NewOffsetPagination(q *Query, defaultLimit int, maxLimit int) (*OffsetPagination, error) {
+	offset, err := parsePageMember(q, "offset", 0, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	limit, err := parsePageMember(q, "limit", defaultLimit, 1, maxLimit)
+	if err != nil {
+		return nil, err
+	}
+	return &OffsetPagination{Offset: offset, Limit: limit}, nil
+}
+
+func NewCursorPagination(q *Query, defaultSize int, maxSize int) (*CursorPagination, error) {
+	size, err := parsePageMember(q, "size", defaultSize, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+	if maxSize > 0 && size > maxSize {
+		e := newParameterError("page[size]", "Page size requested is too large.", fmt.Sprintf("page[size] must not exceed %d", maxSize))
+		e.Links = map[string]interface{}{"type": CursorPaginationProfile + "/max-size-exceeded"}
+		e.Meta = map[string]interface{}{"page": map[string]interface{}{"maxSize": maxSize}}
+		return nil, &ErrorsPayload{Errors: []*JSONAPIError{e}}
+	}
+	return &CursorPagination{Size: size, After: q.Page["after"], Before: q.Page["before"]}, nil
+}
+
+func (p *PageNumberPagination) PaginationLinks(requestURL *url.URL) map[string]interface{} {
+	last := p.lastPage()
+	size := strconv.Itoa(p.Size)
+	links := map[string]interface{}{
+		"first": paginationURL(requestURL, map[string]string{"number": "1", "size": size}),
+		"last":  paginationURL(requestURL, map[string]string{"number": strconv.Itoa(last), "size": size}),
+		"prev":  nil,
+		"next":  nil,
+	}
+	if p.Number > 1 {
+		links["prev"] = paginationURL(requestURL, map[string]string{"number": strconv.Itoa(min(p.Number-1, last)), "size": size})
+	}
+	if p.Number < last {
+		links["next"] = paginationURL(requestURL, map[string]string{"number": strconv.Itoa(p.Number + 1), "size": size})
+	}
+	return links
+}
+
+func (p *PageNumberPagination) PaginationMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"number":     p.Number,
+		"size":       p.Size,
+		"total":      p.Total,
+		"totalPages": p.lastPage(),
+	}
+}
+
+func (p *PageNumberPagination) lastPage() int {
+	if p.Size <= 0 || p.Total <= 0 {
+		return 1
+	}
+	return (p.Total + p.Size - 1) / p.Size
+}
+
+func (p *OffsetPagination) PaginationLinks(requestURL *url.URL) map[string]interface{} {
+	limit := strconv.Itoa(p.Limit)
+	last := 0
+	if p.Limit > 0 && p.Total > 0 {
+		last = (p.Total - 1) / p.Limit * p.Limit
+	}
+	links := map[string]interface{}{
+		"first": paginationURL(requestURL, map[string]string{"offset": "0", "limit": limit}),
+		"last":  paginationURL(requestURL, map[string]string{"offset": strconv.Itoa(last), "limit": limit}),
+		"prev":  nil,
+		"next":  nil,
+	}
+	if p.Offset > 0 {
+		links["prev"] = paginationURL(requestURL, map[string]string{"offset": strconv.Itoa(max(min(p.Offset-p.Limit, last), 0)), "limit": limit})
+	}
+	if p.Offset+p.Limit < p.Total {
+		links["next"] = paginationURL(requestURL, map[string]string{"offset": strconv.Itoa(p.Offset + p.Limit), "limit": limit})
+	}
+	return links
+}
+
+func (p *OffsetPagination) PaginationMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"offset": p.Offset,
+		"limit":  p.Limit,
+		"total":  p.Total,
+	}
+}
+
+func (p *CursorPagination) PaginationLinks(requestURL *url.URL) map[string]interface{} {
+	size := strconv.Itoa(p.Size)
+	links := map[string]interface{}{
+		"first": paginationURL(requestURL, map[string]string{"size": size}),
+		"prev":  nil,
+		"next":  nil,
+	}
+	if p.HasPrev && p.StartCursor != "" {
+		links["prev"] = paginationURL(requestURL, map[string]string{"before": p.StartCursor, "size": size})
+	}
+	if p.HasNext && p.EndCursor != "" {
+		links["next"] = paginationURL(requestURL, map[string]string{"after": p.EndCursor, "size": size})
+	}
+	return links
+}
+
+func (p *CursorPagination) PaginationMeta() map[string]interface{} {
+	meta := map[string]interface{}{}
+	if p.RangeTruncated {
+		meta["rangeTruncated"] = true
+	}
+	if p.EstimatedTotal > 0 {
+		meta["estimatedTotal"] = map[string]interface{}{"bestGuess": p.EstimatedTotal}
+	}
+	return meta
+}
+
+// MixInPagination adds pagination links and meta.page to an encoded document, keeping links and meta already present.
+func MixInPagination(source []byte, requestURL *url.URL, pagination Pagination) ([]byte, error) {
+	raw := make(map[string]interface{})
+	if err := decodeJSON(source, &raw); err != nil {
+		return nil, err
+	}
+
+	links, ok := raw["links"].(map[string]interface{})
+	if !ok {
+		links = map[string]interface{}{}
+	}
+	for k, v := range pagination.PaginationLinks(requestURL) {
+		links[k] = v
+	}
+	raw["links"] = links
+
+	if page := pagination.PaginationMeta(); len(page) > 0 {
+		meta, ok := raw["meta"].(map[string]interface{})
+		if !ok {
+			meta = map[string]interface{}{}
+		}
+		meta["page"] = page
+		raw["meta"] = meta
+	}
+
+	return json.Marshal(raw)
+}
+
+// paginationURL replaces page[...] parameters of the request URL, every other parameter such as include or fields is kept as is.
+func paginationURL(requestURL *url.URL, page map[string]string) string {
+	next := *requestURL
+	query := next.Query()
+	for key := range query {
+		if strings.HasPrefix(key, "page[") {
+			delete(query, key)
+		}
+	}
+	for member, value := range page {
+		query.Set("page["+member+"]", value)
+	}
+	next.RawQuery = query.Encode()
+	return next.String()
+}
+
+func parsePageMember(q *Query, member string, defaultValue int, minValue int, maxValue int) (int, error) {
+	raw, ok := q.Page[member]
+	if !ok {
+		return defaultValue, nil
+	}
+
+	parameter := "page[" + member + "]"
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, &ErrorsPayload{Errors: []*JSONAPIError{newParameterError(parameter, "Invalid page parameter", fmt.Sprintf("%s must be an integer", parameter))}}
+	}
+	if value < minValue {
+		return 0, &ErrorsPayload{Errors: []*JSONAPIError{newParameterError(parameter, "Invalid page parameter", fmt.Sprintf("%s must be at least %d", parameter, minValue))}}
+	}
+	if maxValue > 0 && value > maxValue {
+		return 0, &ErrorsPayload{Errors: []*JSONAPIError{newParameterError(parameter, "Invalid page parameter", fmt.Sprintf("%s must not exceed %d", parameter, maxValue))}}
+	}
+	return value, nil
+}
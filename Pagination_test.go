@@ -0,0 +1,208 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestPageNumberPagination(t *testing.T) {
+
+	t.Run("should build links preserving include and fields", func(t *testing.T) {
+		requestURL, err := url.Parse("https://example.com/articles?include=author&fields%5Barticles%5D=title,body&page%5Bnumber%5D=2&page%5Bsize%5D=10")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		query, err := ParseQuery(requestURL.Query())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pagination, err := NewPageNumberPagination(query, 20, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pagination.Total = 35
+
+		links := pagination.PaginationLinks(requestURL)
+		expected := map[string]string{
+			"first": "page[number]=1",
+			"prev":  "page[number]=1",
+			"next":  "page[number]=3",
+			"last":  "page[number]=4",
+		}
+
+		for name, page := range expected {
+			link, err := url.Parse(links[name].(string))
+			if err != nil {
+				t.Fatal(err)
+			}
+			check := link.Query()
+			expectedPage, _ := url.ParseQuery(page)
+			if check.Get("page[number]") != expectedPage.Get("page[number]") {
+				t.Errorf("unexpected %s link %s", name, links[name])
+			}
+			if check.Get("page[size]") != "10" || check.Get("include") != "author" || check.Get("fields[articles]") != "title,body" {
+				t.Errorf("expected %s link to preserve parameters, got %s", name, links[name])
+			}
+		}
+
+		meta := pagination.PaginationMeta()
+		if meta["totalPages"] != 4 || meta["total"] != 35 {
+			t.Errorf("unexpected meta %+v", meta)
+		}
+	})
+
+	t.Run("should emit null prev and next on the boundaries", func(t *testing.T) {
+		requestURL, _ := url.Parse("/articles")
+		links := (&PageNumberPagination{Number: 1, Size: 10, Total: 5}).PaginationLinks(requestURL)
+
+		if links["prev"] != nil || links["next"] != nil {
+			t.Errorf("unexpected links %+v", links)
+		}
+	})
+
+	t.Run("should reject invalid page parameters", func(t *testing.T) {
+		query, err := ParseQuery(url.Values{"page[number]": {"0"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = NewPageNumberPagination(query, 10, 100)
+		var payload *ErrorsPayload
+		if !errors.As(err, &payload) || payload.Errors[0].Source["parameter"] != "page[number]" {
+			t.Errorf("expected page[number] error, got %v", err)
+		}
+	})
+}
+
+func TestOffsetPagination(t *testing.T) {
+
+	t.Run("should build offset links", func(t *testing.T) {
+		requestURL, _ := url.Parse("/articles?sort=-created")
+		links := (&OffsetPagination{Offset: 20, Limit: 10, Total: 45}).PaginationLinks(requestURL)
+
+		expected := map[string]string{"first": "0", "prev": "10", "next": "30", "last": "40"}
+		for name, offset := range expected {
+			link, _ := url.Parse(links[name].(string))
+			if link.Query().Get("page[offset]") != offset || link.Query().Get("sort") != "-created" {
+				t.Errorf("unexpected %s link %s", name, links[name])
+			}
+		}
+	})
+}
+
+func TestCursorPagination(t *testing.T) {
+
+	t.Run("should build cursor links and page meta", func(t *testing.T) {
+		requestURL, _ := url.Parse("/articles?page%5Bafter%5D=abc&page%5Bsize%5D=2")
+		query, err := ParseQuery(requestURL.Query())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pagination, err := NewCursorPagination(query, 10, 50)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pagination.After != "abc" || pagination.Size != 2 {
+			t.Fatalf("unexpected pagination %+v", pagination)
+		}
+
+		pagination.StartCursor = "c1"
+		pagination.EndCursor = "c2"
+		pagination.HasPrev = true
+		pagination.RangeTruncated = true
+
+		links := pagination.PaginationLinks(requestURL)
+		prev, _ := url.Parse(links["prev"].(string))
+		if prev.Query().Get("page[before]") != "c1" || prev.Query().Get("page[after]") != "" || prev.Query().Get("page[size]") != "2" {
+			t.Errorf("unexpected prev link %s", links["prev"])
+		}
+		if links["next"] != nil {
+			t.Errorf("expected null next link, got %v", links["next"])
+		}
+
+		if pagination.PaginationMeta()["rangeTruncated"] != true {
+			t.Errorf("expected rangeTruncated meta")
+		}
+	})
+
+	t.Run("should report max size exceeded as profile error", func(t *testing.T) {
+		query, _ := ParseQuery(url.Values{"page[size]": {"100"}})
+
+		_, err := NewCursorPagination(query, 10, 50)
+		var payload *ErrorsPayload
+		if !errors.As(err, &payload) {
+			t.Fatalf("expected *ErrorsPayload, got %v", err)
+		}
+		if payload.Errors[0].Links["type"] != CursorPaginationProfile+"/max-size-exceeded" {
+			t.Errorf("unexpected error %+v", payload.Errors[0])
+		}
+	})
+}
+
+func TestMixInPagination(t *testing.T) {
+
+	t.Run("should add links and meta.page keeping existing meta", func(t *testing.T) {
+		type Doc struct {
+			ID string `jsonapi:"primary,collection"`
+		}
+
+		raw, err := MarshalMany([]Doc{{ID: "1"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		raw, err = MixInMeta(raw, map[string]interface{}{"count": 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		requestURL, _ := url.Parse("/collection")
+		extended, err := MixInPagination(raw, requestURL, &PageNumberPagination{Number: 1, Size: 1, Total: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		check := map[string]interface{}{}
+		if err := json.Unmarshal(extended, &check); err != nil {
+			t.Fatal(err)
+		}
+
+		meta := check["meta"].(map[string]interface{})
+		if meta["count"] != float64(1) || meta["page"].(map[string]interface{})["totalPages"] != float64(2) {
+			t.Errorf("unexpected meta %+v", meta)
+		}
+
+		links := check["links"].(map[string]interface{})
+		if links["next"] == nil || links["prev"] != nil {
+			t.Errorf("unexpected links %+v", links)
+		}
+	})
+
+	t.Run("should keep numbers of the document exact", func(t *testing.T) {
+		type Doc struct {
+			ID    string `jsonapi:"primary,collection"`
+			Count int64  `jsonapi:"attr,count"`
+		}
+
+		raw, err := MarshalOne(Doc{ID: "1", Count: 9007199254740993})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		requestURL, _ := url.Parse("/collection")
+		extended, err := MixInPagination(raw, requestURL, &PageNumberPagination{Number: 1, Size: 1, Total: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.Contains(string(extended), `"count":9007199254740993`) {
+			t.Errorf("expected exact count, got %s", extended)
+		}
+	})
+}
@@ -1,7 +1,6 @@
 package jsonapi
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
@@ -14,23 +13,28 @@ type PatchOp struct {
 	Value interface{} `json:"value"`
 }
 
-func UnmarshalPatches(data []byte, model reflect.Type) (patches []PatchOp, err error) {
+func UnmarshalPatches(data []byte, model reflect.Type, opts ...Option) (patches []PatchOp, err error) {
 	patches = make([]PatchOp, 0)
-	err = json.Unmarshal(data, &patches)
+	err = decodeJSON(data, &patches)
 	if err != nil {
 		return nil, err
 	}
 
-	return UnmarshalPatchesSlice(patches, model)
+	return UnmarshalPatchesSlice(patches, model, opts...)
 }
 
-func UnmarshalPatchesSlice(patches []PatchOp, model reflect.Type) (out []PatchOp, err error) {
+func UnmarshalPatchesSlice(patches []PatchOp, model reflect.Type, opts ...Option) (out []PatchOp, err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			err = fmt.Errorf("[jsonapi.UnmarshalPatchesSlice] recovered from: %w", r.(error))
+			if rErr, ok := r.(error); ok {
+				err = fmt.Errorf("[jsonapi.UnmarshalPatchesSlice] recovered from: %w", rErr)
+			} else {
+				err = fmt.Errorf("[jsonapi.UnmarshalPatchesSlice] recovered from: %v", r)
+			}
 		}
 	}()
 
+	o := newOptions(opts)
 	modelVal := reflect.New(model.Elem())
 	if modelVal.Kind() != reflect.Ptr && modelVal.Elem().Kind() != reflect.Struct {
 		return nil, errors.New("invalid model type")
@@ -45,13 +49,19 @@ func UnmarshalPatchesSlice(patches []PatchOp, model reflect.Type) (out []PatchOp
 
 			patchPathParts := strings.Split(strings.TrimPrefix(patch.Path, "/"), "/")
 
-			fieldVal, jsonapiType, err := digIn(modelVal, patchPathParts)
+			fieldVal, jsonapiType, fieldOpts, err := digIn(modelVal, patchPathParts, o)
 			if err != nil {
 				return nil, fmt.Errorf("failed to dig into patch path: %w", err)
 			}
 
 			if fieldVal.IsValid() {
-				if jsonapiType == "relation" {
+				if jsonapiType == "relation" && isForeignKeyType(fieldVal.Type()) {
+					target := reflect.New(fieldVal.Type()).Elem()
+					if err := setForeignKeyValue(target, patch.Value, o); err != nil {
+						return nil, fmt.Errorf("failed to unmarshal referenced ID: %w", err)
+					}
+					patches[i].Value = target.Interface()
+				} else if jsonapiType == "relation" {
 					fieldPrimitiveType := fieldVal.Type()
 					fieldPrimitiveVal := fieldVal
 
@@ -68,30 +78,39 @@ func UnmarshalPatchesSlice(patches []PatchOp, model reflect.Type) (out []PatchOp
 						if !ok {
 							return nil, errors.New("invalid patch operation - cannot target slice with non-slice value with replace")
 						}
-						idFieldType, idFieldVal, resourceName := getIdFieldVal(fieldPrimitiveType, fieldPrimitiveVal)
+						idFieldType, idFieldVal, err := getIdFieldVal(fieldPrimitiveType, fieldPrimitiveVal)
+						if err != nil {
+							return nil, err
+						}
 						for i, item := range list {
-							if err := unmarshalID(idFieldType, idFieldVal, item, resourceName); err != nil {
+							if err := unmarshalID(idFieldType, idFieldVal, item, o); err != nil {
 								return nil, fmt.Errorf("failed to unmarshal referenced ID: %w", err)
 							}
 							list[i] = idFieldVal.Interface()
 						}
 						patches[i].Value = list
 					} else {
-						idFieldType, idFieldVal, resourceName := getIdFieldVal(fieldPrimitiveType, fieldPrimitiveVal)
-						if err := unmarshalID(idFieldType, idFieldVal, patch.Value, resourceName); err != nil {
+						idFieldType, idFieldVal, err := getIdFieldVal(fieldPrimitiveType, fieldPrimitiveVal)
+						if err != nil {
+							return nil, err
+						}
+						if err := unmarshalID(idFieldType, idFieldVal, patch.Value, o); err != nil {
 							return nil, fmt.Errorf("failed to unmarshal referenced ID: %w", err)
 						}
 						patches[i].Value = idFieldVal.Interface()
 					}
 				} else {
-					unmarshalSingleAttribute(fieldVal, patch.Value)
+					unmarshalSingleAttribute(fieldVal, patch.Value, fieldOpts)
 					patches[i].Value = fieldVal.Interface()
 				}
+			} else {
+				//Not cast to a model type, numbers are passed on as float64 like encoding/json decodes them
+				patches[i].Value = normalizeNumbers(patch.Value)
 			}
 		case "add":
 			//Applicable to lists only, so need to validate the targets
 			patchPathParts := strings.Split(strings.TrimPrefix(patch.Path, "/"), "/")
-			fieldVal, jsonapiType, err := digIn(modelVal, patchPathParts)
+			fieldVal, jsonapiType, fieldOpts, err := digIn(modelVal, patchPathParts, o)
 			if err != nil {
 				return nil, fmt.Errorf("failed to dig into patch path: %w", err)
 			}
@@ -112,17 +131,25 @@ func UnmarshalPatchesSlice(patches []PatchOp, model reflect.Type) (out []PatchOp
 					}
 					fieldPrimitiveVal := reflect.New(fieldPrimitiveType).Elem()
 
-					if jsonapiType == "relation" {
+					if jsonapiType == "relation" && isForeignKeyType(fieldPrimitiveType) {
+						if err := setIDValue(fieldPrimitiveVal, patch.Value, o); err != nil {
+							return nil, fmt.Errorf("failed to unmarshal referenced ID: %w", err)
+						}
+						patches[i].Value = fieldPrimitiveVal.Interface()
+					} else if jsonapiType == "relation" {
 						//In case of relation we can only receive id value, but the target type is unknown
 						//fieldPrimitiveVal is now relation value which is a nil struct that should have a primary field somewhere
 						//Should be similar to modelType and modelVal in unmarshalOne here
-						idFieldType, idFieldVal, resourceName := getIdFieldVal(fieldPrimitiveType, fieldPrimitiveVal)
-						if err := unmarshalID(idFieldType, idFieldVal, patch.Value, resourceName); err != nil {
+						idFieldType, idFieldVal, err := getIdFieldVal(fieldPrimitiveType, fieldPrimitiveVal)
+						if err != nil {
+							return nil, err
+						}
+						if err := unmarshalID(idFieldType, idFieldVal, patch.Value, o); err != nil {
 							return nil, fmt.Errorf("failed to unmarshal referenced ID: %w", err)
 						}
 						patches[i].Value = idFieldVal.Interface()
 					} else {
-						unmarshalSingleAttribute(fieldPrimitiveVal, patch.Value)
+						unmarshalSingleAttribute(fieldPrimitiveVal, patch.Value, fieldOpts)
 						patches[i].Value = fieldPrimitiveVal.Interface()
 					}
 				} else {
@@ -134,6 +161,7 @@ func UnmarshalPatchesSlice(patches []PatchOp, model reflect.Type) (out []PatchOp
 		case "":
 			return nil, errors.New("invalid patch operation - empty op")
 		default:
+			patches[i].Value = normalizeNumbers(patch.Value)
 			continue //Stepping over other options for now for compatibility
 		}
 	}
@@ -141,15 +169,14 @@ func UnmarshalPatchesSlice(patches []PatchOp, model reflect.Type) (out []PatchOp
 	return patches, nil
 }
 
-func digIn(modelVal reflect.Value, pathParts []string) (reflect.Value, string, error) {
+func digIn(modelVal reflect.Value, pathParts []string, opts *options) (reflect.Value, string, *options, error) {
 	if modelVal.Elem().Kind() != reflect.Struct {
-		return modelVal.Elem(), pathParts[0], nil
+		return modelVal.Elem(), pathParts[0], opts, nil
 	}
 
 	var fieldVal reflect.Value
 	var jsonapiType string
-	for i := 0; i < modelVal.Elem().NumField(); i++ {
-		field := modelVal.Elem().Type().Field(i)
+	for _, field := range resourceFields(modelVal.Elem().Type()) {
 		jsonapiType = getJsonapiFieldType(field)
 		if jsonapiType == "" {
 			jsonapiType = "attr" //Defaults to attr if not set or only json tag is provided
@@ -159,51 +186,53 @@ func digIn(modelVal reflect.Value, pathParts []string) (reflect.Value, string, e
 		//Primary cannot be patched, reference types are managed on the application level
 		attrName := getAttributeName(field)
 		if attrName == pathParts[0] {
-			fieldVal = modelVal.Elem().Field(i)
+			fieldVal = fieldByIndexAlloc(modelVal.Elem(), field.Index)
+			opts = opts.forField(field)
+			if jsonapiType == "relation" && isRefType(fieldVal.Type()) {
+				//Patches carry the relationship value, Ref state is not part of the patch
+				fieldVal = fieldVal.FieldByName("Value")
+			}
 			break
 		}
 	}
 
 	if len(pathParts) > 1 {
 		if fieldVal.Kind() == reflect.Ptr {
-			return digIn(reflect.New(fieldVal.Type().Elem()), pathParts[1:])
+			return digIn(reflect.New(fieldVal.Type().Elem()), pathParts[1:], opts)
 		}
 		if fieldVal.Kind() == reflect.Struct {
-			return digIn(reflect.New(fieldVal.Type()), pathParts[1:])
+			return digIn(reflect.New(fieldVal.Type()), pathParts[1:], opts)
 		}
 		if fieldVal.Kind() == reflect.Map {
 			if len(pathParts[1:]) == 1 {
 				//Patch is targeting one of map keys directly, the value then is value of the map itself
-				return reflect.New(fieldVal.Type().Elem()).Elem(), "attr", nil
+				return reflect.New(fieldVal.Type().Elem()).Elem(), "attr", opts, nil
 			} else {
 				//Patch is targeting a path inside the map. Step over one more key which is the map key
-				return digIn(reflect.New(fieldVal.Type().Elem()), pathParts[2:])
+				return digIn(reflect.New(fieldVal.Type().Elem()), pathParts[2:], opts)
 			}
 		}
 	}
 
-	return fieldVal, jsonapiType, nil
+	return fieldVal, jsonapiType, opts, nil
 }
 
-func getIdFieldVal(modelType reflect.Type, modelVal reflect.Value) (reflect.StructField, reflect.Value, string) {
+func getIdFieldVal(modelType reflect.Type, modelVal reflect.Value) (reflect.StructField, reflect.Value, error) {
 	if modelType.Kind() == reflect.Ptr { //Unwrap potential pointer
 		modelType = modelType.Elem()
 		modelVal = reflect.New(modelType).Elem()
 	}
 
-	for i := 0; i < modelType.NumField(); i++ {
-		fieldType := modelType.Field(i)
-		fieldVal := modelVal.Field(i)
+	if modelType.Kind() == reflect.Interface {
+		//Patch values only carry ids, the concrete type of the related resource is unknown
+		return reflect.StructField{}, reflect.Value{}, fmt.Errorf("invalid patch operation - cannot resolve ids of %s relationship holding resources of any type", modelType)
+	}
 
-		tag := fieldType.Tag.Get("jsonapi")
-		if tag != "" {
-			parts := strings.Split(tag, ",")
-			if parts[0] == "primary" {
-				return fieldType, fieldVal, parts[1]
-			}
+	for _, fieldType := range resourceFields(modelType) {
+		if isIDField(fieldType) {
+			return fieldType, fieldByIndexAlloc(modelVal, fieldType.Index), nil
 		}
 	}
 
-	//No primary field found on relationship, has to be a configuration mistake so panic here is appropriate
-	panic(errors.New("no primary field found on relationship field type"))
+	return reflect.StructField{}, reflect.Value{}, errors.New("no primary field found on relationship field type")
 }
@@ -210,6 +210,69 @@ func TestUnmarshalPatches_Replace(t *testing.T) {
 		}
 	})
 
+	t.Run("should correctly unmarshal references with custom primary field", func(t *testing.T) {
+		raw := `[
+			{"op": "replace", "path": "/owner", "value": "7"},
+			{"op": "replace", "path": "/refs", "value": ["0102aaff"]}
+		]`
+
+		type Base struct {
+			Key *uint `jsonapi:"primary,owners"`
+		}
+		type Owner struct {
+			Base
+			Name string `jsonapi:"attr,name"`
+		}
+		type Ref struct {
+			UUID StringSerializable `jsonapi:"primary,refs"`
+		}
+		type SUT struct {
+			ID    string `jsonapi:"primary,tests"`
+			Owner *Owner `jsonapi:"relation,owner"`
+			Refs  []Ref  `jsonapi:"relation,refs"`
+		}
+
+		parsed, err := UnmarshalPatches([]byte(raw), reflect.TypeOf(new(SUT)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if *parsed[0].Value.(*uint) != 7 {
+			t.Errorf("expected 7, got %v", parsed[0].Value)
+		}
+
+		if parsed[1].Value.([]interface{})[0].(StringSerializable) != (StringSerializable{1, 2, 0xaa, 0xff}) {
+			t.Errorf("unexpected value %v", parsed[1].Value)
+		}
+	})
+
+	t.Run("should apply time format of the targeted attribute", func(t *testing.T) {
+		raw := `[
+			{"op": "replace", "path": "/at", "value": 1514862245},
+			{"op": "replace", "path": "/nested/at", "value": "2018-01-02"}
+		]`
+
+		type SUT struct {
+			ID     string    `jsonapi:"primary,tests"`
+			At     time.Time `jsonapi:"attr,at,unix"`
+			Nested struct {
+				At time.Time `json:"at"`
+			} `jsonapi:"attr,nested"`
+		}
+
+		parsed, err := UnmarshalPatches([]byte(raw), reflect.TypeOf(new(SUT)), WithTimeFormat("date"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !parsed[0].Value.(time.Time).Equal(time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)) {
+			t.Errorf("unexpected value %v", parsed[0].Value)
+		}
+		if !parsed[1].Value.(time.Time).Equal(time.Date(2018, 1, 2, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("unexpected value %v", parsed[1].Value)
+		}
+	})
+
 	t.Run("should correctly unmarshal with TextUnmarshaller", func(t *testing.T) {
 		raw := `[
 			{"op": "replace", "path": "/byVal", "value": "0102aaff"},
@@ -415,6 +478,48 @@ func TestUnmarshalPatches_Replace(t *testing.T) {
 		}
 	})
 
+	t.Run("should report patches of relations holding resources of any type", func(t *testing.T) {
+		type SUT struct {
+			ID          string       `jsonapi:"primary,tests"`
+			Cover       attachment   `jsonapi:"relation,cover"`
+			Attachments []attachment `jsonapi:"relation,attachments"`
+		}
+
+		for _, raw := range []string{
+			`[{"op": "replace", "path": "/cover", "value": "5"}]`,
+			`[{"op": "replace", "path": "/attachments", "value": ["5"]}]`,
+			`[{"op": "add", "path": "/attachments", "value": "5"}]`,
+		} {
+			if _, err := UnmarshalPatches([]byte(raw), reflect.TypeOf(new(SUT))); err == nil {
+				t.Errorf("expected error for %s", raw)
+			}
+		}
+	})
+
+	t.Run("should keep numbers not cast to the model as float64", func(t *testing.T) {
+		raw := `[
+			{"op": "replace", "path": "/unknown", "value": 1},
+			{"op": "remove", "path": "/int", "value": 2},
+			{"op": "move", "path": "/int", "value": {"nested": [3]}}
+		]`
+
+		type SUT struct {
+			ID  string `jsonapi:"primary,tests"`
+			Int int    `jsonapi:"attr,int"`
+		}
+
+		parsed, err := UnmarshalPatches([]byte(raw), reflect.TypeOf(new(SUT)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if parsed[0].Value != float64(1) || parsed[1].Value != float64(2) {
+			t.Fatalf("expected float64 values, got %T and %T", parsed[0].Value, parsed[1].Value)
+		}
+		if !reflect.DeepEqual(parsed[2].Value, map[string]interface{}{"nested": []interface{}{float64(3)}}) {
+			t.Fatalf("expected nested float64 values, got %#v", parsed[2].Value)
+		}
+	})
 }
 
 func TestUnmarshalPatches_Add(t *testing.T) {
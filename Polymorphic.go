@@ -0,0 +1,63 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// WithTypeMap sets model types to unmarshal resources into by their resource type, when the target is an interface:
+// a slice of an interface type passed to Unmarshal, or an interface typed relationship field. Model types are structs
+// or pointers to structs, and they may be registered for resource types other than the one of their primary tag.
+// Targets receive a pointer to the model if it implements the target interface, the model itself otherwise.
+func WithTypeMap(types map[string]reflect.Type) Option {
+	return func(o *options) {
+		o.typeMap = make(map[string]reflect.Type, len(types))
+		for resourceType, modelType := range types {
+			if modelType.Kind() == reflect.Pointer {
+				modelType = modelType.Elem()
+			}
+			o.typeMap[resourceType] = modelType
+		}
+	}
+}
+
+// UnmarshalManyAsTypes unmarshals primary data holding resources of different types. Every resource is unmarshaled
+// into the model type registered for its resource type, and returned as a pointer to it.
+func UnmarshalManyAsTypes(payload []byte, types map[string]reflect.Type, opts ...Option) ([]interface{}, error) {
+	out := make([]interface{}, 0)
+	if err := Unmarshal(payload, &out, append(opts, WithTypeMap(types))...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// unmarshalPolymorphic unmarshals the resource into the model type registered for its resource type and returns it
+// as a value of the target interface type.
+func unmarshalPolymorphic(data map[string]interface{}, target reflect.Type, graph *unmarshalGraph, opts *options) (reflect.Value, error) {
+	resourceType, ok := data["type"].(string)
+	if !ok {
+		return reflect.Value{}, errors.New("invalid data structure - missing resource type")
+	}
+	modelType, ok := opts.typeMap[resourceType]
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("no model type registered for resource type %s", resourceType)
+	}
+	if modelType.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("invalid model type %s for resource type %s, resources must be structs", modelType, resourceType)
+	}
+
+	instance, err := unmarshalInstance(data, modelType, resourceType, graph, opts)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	switch {
+	case instance.Type().AssignableTo(target):
+		return instance, nil
+	case modelType.AssignableTo(target):
+		return instance.Elem(), nil
+	default:
+		return reflect.Value{}, fmt.Errorf("model type %s for resource type %s does not implement %s", modelType, resourceType, target)
+	}
+}
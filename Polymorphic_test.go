@@ -0,0 +1,110 @@
+package jsonapi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnmarshalPolymorphic(t *testing.T) {
+
+	type Person struct {
+		ID   string `jsonapi:"primary,people"`
+		Name string `jsonapi:"attr,name"`
+	}
+
+	type Article struct {
+		ID     string  `jsonapi:"primary,articles"`
+		Title  string  `jsonapi:"attr,title"`
+		Author *Person `jsonapi:"relation,author"`
+	}
+
+	types := map[string]reflect.Type{
+		"articles": reflect.TypeOf(Article{}),
+		"people":   reflect.TypeOf(&Person{}),
+		"images":   reflect.TypeOf(attachmentImage{}),
+		"videos":   reflect.TypeOf(attachmentVideo{}),
+	}
+
+	search := []byte(`{
+		"data":[
+			{"type":"articles","id":"1","attributes":{"title":"t"},"relationships":{"author":{"data":{"type":"people","id":"2"}}}},
+			{"type":"people","id":"2","attributes":{"name":"a"}}
+		]
+	}`)
+
+	t.Run("should dispatch mixed primary data by resource type", func(t *testing.T) {
+		out, err := UnmarshalManyAsTypes(search, types)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(out) != 2 {
+			t.Fatal("unexpected number of resources", out)
+		}
+
+		article, ok := out[0].(*Article)
+		if !ok || article.Title != "t" {
+			t.Fatal("unexpected article", out[0])
+		}
+		person, ok := out[1].(*Person)
+		if !ok || person.Name != "a" {
+			t.Fatal("unexpected person", out[1])
+		}
+		if article.Author != person {
+			t.Fatal("expected author to share the primary instance")
+		}
+	})
+
+	t.Run("should fill slices of interface types", func(t *testing.T) {
+		raw := []byte(`{"data":[
+			{"type":"images","id":"1","attributes":{"width":100}},
+			{"type":"videos","id":"2","attributes":{"duration":60}}
+		]}`)
+
+		out := make([]attachment, 0)
+		if err := Unmarshal(raw, &out, WithTypeMap(types)); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []attachment{&attachmentImage{ID: "1", Width: 100}, &attachmentVideo{ID: "2", Duration: 60}}
+		if !reflect.DeepEqual(out, expected) {
+			t.Errorf("expected %+v, got %+v", expected, out)
+		}
+	})
+
+	t.Run("should fill interface typed relationships", func(t *testing.T) {
+		type Post struct {
+			ID          string       `jsonapi:"primary,posts"`
+			Cover       attachment   `jsonapi:"relation,cover"`
+			Attachments []attachment `jsonapi:"relation,attachments"`
+		}
+
+		input := Post{
+			ID:          "1",
+			Cover:       &attachmentImage{ID: "2", Width: 100},
+			Attachments: []attachment{&attachmentVideo{ID: "3", Duration: 60}, &attachmentImage{ID: "2", Width: 100}},
+		}
+		raw, err := Marshal(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out := Post{}
+		if err := Unmarshal(raw, &out, WithTypeMap(types)); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(out, input) {
+			t.Errorf("expected %+v, got %+v", input, out)
+		}
+	})
+
+	t.Run("should reject unknown and incompatible resource types", func(t *testing.T) {
+		if _, err := UnmarshalManyAsTypes(search, map[string]reflect.Type{"articles": reflect.TypeOf(Article{})}); err == nil {
+			t.Fatal("expected unknown resource type error")
+		}
+
+		out := make([]attachment, 0)
+		if err := Unmarshal(search, &out, WithTypeMap(types)); err == nil {
+			t.Fatal("expected incompatible model type error")
+		}
+	})
+}
@@ -0,0 +1,371 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Query holds the JSON:API specific query parameters of a request in a parsed form.
+type Query struct {
+	//Include lists the relationship paths requested with the include parameter, each split into its segments
+	Include [][]string
+	//Fields maps a resource type to the sparse fieldset requested for it. An empty list means no fields are requested
+	Fields map[string][]string
+	//Sort lists the sort fields in the order of their precedence
+	Sort []SortField
+	//Page holds the page[...] family members keyed by the member name, e.g. "number" or "size"
+	Page map[string]string
+}
+
+type SortField struct {
+	Field      string
+	Descending bool
+}
+
+func (s SortField) String() string {
+	if s.Descending {
+		return "-" + s.Field
+	}
+	return s.Field
+}
+
+// ParseQuery extracts include, fields, sort and page parameters from the query.
+// Any other parameter is left untouched. All malformed parameters are reported at once as *ErrorsPayload.
+func ParseQuery(query url.Values) (*Query, error) {
+	out := &Query{
+		Include: make([][]string, 0),
+		Fields:  map[string][]string{},
+		Sort:    make([]SortField, 0),
+		Page:    map[string]string{},
+	}
+	errs := make([]*JSONAPIError, 0)
+
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys) //Stable order of reported errors
+
+	for _, key := range keys {
+		values := query[key]
+		family, members, ok := splitParameterFamily(key)
+		if !ok {
+			if strings.HasPrefix(key, "fields") || strings.HasPrefix(key, "page") {
+				errs = append(errs, newParameterError(key, "Invalid query parameter", fmt.Sprintf("malformed parameter name %s", key)))
+			}
+			continue
+		}
+
+		switch family {
+		case "include":
+			if len(members) > 0 {
+				errs = append(errs, newParameterError(key, "Invalid query parameter", "include parameter does not accept members"))
+				continue
+			}
+			for _, value := range values {
+				paths, err := parseIncludeValue(key, value)
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				out.Include = append(out.Include, paths...)
+			}
+		case "fields":
+			if len(members) != 1 || members[0] == "" {
+				errs = append(errs, newParameterError(key, "Invalid query parameter", "fields parameter must be in fields[type] form"))
+				continue
+			}
+			fields, err := parseListValue(key, strings.Join(values, ","), true)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			out.Fields[members[0]] = append(out.Fields[members[0]], fields...)
+		case "sort":
+			if len(members) > 0 {
+				errs = append(errs, newParameterError(key, "Invalid query parameter", "sort parameter does not accept members"))
+				continue
+			}
+			for _, value := range values {
+				fields, err := parseListValue(key, value, false)
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				for _, field := range fields {
+					sortField := SortField{Field: strings.TrimPrefix(field, "-"), Descending: strings.HasPrefix(field, "-")}
+					if sortField.Field == "" {
+						errs = append(errs, newParameterError(key, "Invalid query parameter", "sort field name cannot be empty"))
+						continue
+					}
+					out.Sort = append(out.Sort, sortField)
+				}
+			}
+		case "page":
+			if len(members) != 1 || members[0] == "" {
+				errs = append(errs, newParameterError(key, "Invalid query parameter", "page parameter must be in page[member] form"))
+				continue
+			}
+			if len(values) > 1 {
+				errs = append(errs, newParameterError(key, "Invalid query parameter", fmt.Sprintf("%s cannot be provided more than once", key)))
+				continue
+			}
+			out.Page[members[0]] = values[0]
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, &ErrorsPayload{Errors: errs}
+	}
+
+	return out, nil
+}
+
+// HasInclude reports whether the dot-separated relationship path is requested for inclusion.
+// Intermediate paths are considered included as well, e.g. "author" is included with "author.company".
+func (q *Query) HasInclude(path string) bool {
+	segments := strings.Split(path, ".")
+	for _, include := range q.Include {
+		if len(include) >= len(segments) && slices.Equal(include[:len(segments)], segments) {
+			return true
+		}
+	}
+	return false
+}
+
+// FieldsFor returns the sparse fieldset for the resource type, ok is false when no fieldset is requested for it.
+func (q *Query) FieldsFor(resourceType string) (fields []string, ok bool) {
+	fields, ok = q.Fields[resourceType]
+	return fields, ok
+}
+
+// splitParameterFamily splits "family[member1][member2]" into its family name and a list of members.
+func splitParameterFamily(key string) (string, []string, bool) {
+	open := strings.IndexByte(key, '[')
+	if open == -1 {
+		if strings.ContainsRune(key, ']') {
+			return "", nil, false
+		}
+		return key, []string{}, true
+	}
+
+	family := key[:open]
+	if family == "" {
+		return "", nil, false
+	}
+
+	members := make([]string, 0)
+	rest := key[open:]
+	for rest != "" {
+		if rest[0] != '[' {
+			return "", nil, false
+		}
+		closing := strings.IndexByte(rest, ']')
+		if closing == -1 {
+			return "", nil, false
+		}
+		member := rest[1:closing]
+		if strings.ContainsRune(member, '[') {
+			return "", nil, false
+		}
+		members = append(members, member)
+		rest = rest[closing+1:]
+	}
+
+	return family, members, true
+}
+
+func parseIncludeValue(parameter string, value string) ([][]string, *JSONAPIError) {
+	paths := make([][]string, 0)
+	list, err := parseListValue(parameter, value, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range list {
+		segments := strings.Split(path, ".")
+		if slices.Contains(segments, "") {
+			return nil, newParameterError(parameter, "Invalid query parameter", fmt.Sprintf("malformed relationship path %q", path))
+		}
+		paths = append(paths, segments)
+	}
+	return paths, nil
+}
+
+func parseListValue(parameter string, value string, allowEmpty bool) ([]string, *JSONAPIError) {
+	if value == "" {
+		if allowEmpty {
+			return []string{}, nil
+		}
+		return nil, newParameterError(parameter, "Invalid query parameter", fmt.Sprintf("%s cannot be empty", parameter))
+	}
+
+	list := strings.Split(value, ",")
+	for _, item := range list {
+		if item == "" {
+			return nil, newParameterError(parameter, "Invalid query parameter", fmt.Sprintf("%s contains an empty list item", parameter))
+		}
+	}
+	return list, nil
+}
+
+// ValidateQuery parses the query and checks include, fields and sort parameters against the model type.
+// Include paths have to follow relation tagged fields, sparse fieldsets have to name attributes or relationships
+// of the resource types reachable from the model, and sort fields have to resolve to attributes.
+func ValidateQuery(query url.Values, model reflect.Type) (*Query, error) {
+	parsed, err := ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	modelType, ok := structTypeOf(model)
+	if !ok {
+		return nil, errors.New("invalid model type")
+	}
+
+	errs := make([]*JSONAPIError, 0)
+
+	for _, include := range parsed.Include {
+		if _, err := walkRelationshipPath(modelType, include); err != nil {
+			errs = append(errs, newParameterError("include", "Invalid query parameter", err.Error()))
+		}
+	}
+
+	resourceTypes := reachableResourceTypes(modelType)
+	fieldsetTypes := make([]string, 0, len(parsed.Fields))
+	for resourceType := range parsed.Fields {
+		fieldsetTypes = append(fieldsetTypes, resourceType)
+	}
+	sort.Strings(fieldsetTypes)
+
+	for _, resourceType := range fieldsetTypes {
+		parameter := "fields[" + resourceType + "]"
+		structType, ok := resourceTypes[resourceType]
+		if !ok {
+			errs = append(errs, newParameterError(parameter, "Invalid query parameter", fmt.Sprintf("unknown resource type %s", resourceType)))
+			continue
+		}
+
+		attributes, relationships := resourceSchema(structType)
+		for _, field := range parsed.Fields[resourceType] {
+			_, isAttribute := attributes[field]
+			_, isRelationship := relationships[field]
+			if !isAttribute && !isRelationship {
+				errs = append(errs, newParameterError(parameter, "Invalid query parameter", fmt.Sprintf("unknown field %s on resource type %s", field, resourceType)))
+			}
+		}
+	}
+
+	for _, sortField := range parsed.Sort {
+		segments := strings.Split(sortField.Field, ".")
+		target, err := walkRelationshipPath(modelType, segments[:len(segments)-1])
+		if err != nil {
+			errs = append(errs, newParameterError("sort", "Invalid query parameter", err.Error()))
+			continue
+		}
+		attributes, _ := resourceSchema(target)
+		if _, ok := attributes[segments[len(segments)-1]]; !ok {
+			errs = append(errs, newParameterError("sort", "Invalid query parameter", fmt.Sprintf("cannot sort by %s", sortField.Field)))
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, &ErrorsPayload{Errors: errs}
+	}
+
+	return parsed, nil
+}
+
+// resourceSchema classifies the fields of a resource struct type by their encoded names the same way getAttributes
+// and getRelationships do.
+func resourceSchema(modelType reflect.Type) (attributes map[string]reflect.StructField, relationships map[string]reflect.StructField) {
+	attributes = map[string]reflect.StructField{}
+	relationships = map[string]reflect.StructField{}
+
+	for _, field := range resourceFields(modelType) {
+		encodedFieldName := getEncodedFieldName(field)
+		if encodedFieldName == "-" {
+			continue
+		}
+
+		jsonapiTag := field.Tag.Get("jsonapi")
+		if jsonapiTag != "" {
+			switch strings.Split(jsonapiTag, ",")[0] {
+			case "attr":
+				attributes[encodedFieldName] = field
+			case "relation":
+				relationships[encodedFieldName] = field
+			}
+		} else if field.Tag.Get("json") != "" || field.IsExported() {
+			attributes[encodedFieldName] = field
+		}
+	}
+
+	return attributes, relationships
+}
+
+// walkRelationshipPath follows the relationship names from the model type and returns the struct type at the end of the path.
+func walkRelationshipPath(modelType reflect.Type, path []string) (reflect.Type, error) {
+	current := modelType
+	for i, segment := range path {
+		_, relationships := resourceSchema(current)
+		field, ok := relationships[segment]
+		if !ok {
+			return nil, fmt.Errorf("unknown relationship path %s", strings.Join(path[:i+1], "."))
+		}
+		next, ok := structTypeOf(relationValueType(field.Type))
+		if !ok || isForeignKeyType(field.Type) {
+			return nil, fmt.Errorf("relationship %s does not reference a resource", strings.Join(path[:i+1], "."))
+		}
+		current = next
+	}
+	return current, nil
+}
+
+// reachableResourceTypes maps resource type names to struct types of the model and every resource reachable through its relationships.
+func reachableResourceTypes(modelType reflect.Type) map[string]reflect.Type {
+	out := map[string]reflect.Type{}
+	queue := []reflect.Type{modelType}
+	visited := map[reflect.Type]bool{}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+
+		if resourceType, err := getResourceType(reflect.New(current).Elem(), current); err == nil {
+			if _, ok := out[resourceType]; !ok {
+				out[resourceType] = current
+			}
+		}
+
+		_, relationships := resourceSchema(current)
+		for _, field := range relationships {
+			if next, ok := structTypeOf(relationValueType(field.Type)); ok && !isForeignKeyType(field.Type) {
+				queue = append(queue, next)
+				if tag, err := parseRelationTag(field); err == nil && tag.typeOverride != "" {
+					if _, ok := out[tag.typeOverride]; !ok {
+						out[tag.typeOverride] = next
+					}
+				}
+			}
+		}
+	}
+
+	return out
+}
+
+// structTypeOf unwraps pointers and slices down to the struct type of a model or a relationship field.
+func structTypeOf(t reflect.Type) (reflect.Type, bool) {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	return t, t.Kind() == reflect.Struct
+}
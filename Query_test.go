@@ -0,0 +1,166 @@
+package jsonapi
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+
+	t.Run("should parse include, fields, sort and page parameters", func(t *testing.T) {
+		query, err := url.ParseQuery("include=author,comments.author&fields[articles]=title,body&fields[people]=name&sort=-created,title&page[number]=2&page[size]=10&filter[title]=x")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out, err := ParseQuery(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(out.Include, [][]string{{"author"}, {"comments", "author"}}) {
+			t.Errorf("unexpected include %+v", out.Include)
+		}
+
+		if !reflect.DeepEqual(out.Fields, map[string][]string{"articles": {"title", "body"}, "people": {"name"}}) {
+			t.Errorf("unexpected fields %+v", out.Fields)
+		}
+
+		if !reflect.DeepEqual(out.Sort, []SortField{{Field: "created", Descending: true}, {Field: "title"}}) {
+			t.Errorf("unexpected sort %+v", out.Sort)
+		}
+
+		if !reflect.DeepEqual(out.Page, map[string]string{"number": "2", "size": "10"}) {
+			t.Errorf("unexpected page %+v", out.Page)
+		}
+	})
+
+	t.Run("should expose include paths and fieldsets to the handler", func(t *testing.T) {
+		out, err := ParseQuery(url.Values{"include": {"comments.author"}, "fields[people]": {""}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !out.HasInclude("comments") || !out.HasInclude("comments.author") {
+			t.Errorf("expected comments and comments.author to be included")
+		}
+		if out.HasInclude("author") || out.HasInclude("comments.author.company") {
+			t.Errorf("unexpected include match")
+		}
+
+		fields, ok := out.FieldsFor("people")
+		if !ok || len(fields) != 0 {
+			t.Errorf("expected empty fieldset for people, got %+v", fields)
+		}
+		if _, ok := out.FieldsFor("articles"); ok {
+			t.Errorf("expected no fieldset for articles")
+		}
+	})
+
+	t.Run("should report malformed parameters with source parameter", func(t *testing.T) {
+		query := url.Values{
+			"include":      {"author..name"},
+			"fields":       {"title"},
+			"sort":         {"title,,-"},
+			"page[a][b]":   {"1"},
+			"page[number]": {"1", "2"},
+			"fields[x":     {"y"},
+		}
+
+		_, err := ParseQuery(query)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+
+		var payload *ErrorsPayload
+		if !errors.As(err, &payload) {
+			t.Fatalf("expected *ErrorsPayload, got %T", err)
+		}
+
+		parameters := map[string]bool{}
+		for _, e := range payload.Errors {
+			if e.Status != "400" {
+				t.Errorf("unexpected status %s", e.Status)
+			}
+			parameters[e.Source["parameter"].(string)] = true
+		}
+
+		for _, expected := range []string{"include", "fields", "sort", "page[a][b]", "page[number]", "fields[x"} {
+			if !parameters[expected] {
+				t.Errorf("expected error for %s, got %+v", expected, parameters)
+			}
+		}
+	})
+}
+
+func TestValidateQuery(t *testing.T) {
+	type Company struct {
+		ID   string `jsonapi:"primary,companies"`
+		Name string `jsonapi:"attr,name"`
+	}
+
+	type Person struct {
+		ID           string   `jsonapi:"primary,people"`
+		Name         string   `jsonapi:"attr,name"`
+		PasswordHash string   `json:"-"`
+		Company      *Company `jsonapi:"relation,company"`
+	}
+
+	type Article struct {
+		ID       string    `jsonapi:"primary,articles"`
+		Title    string    `jsonapi:"attr,title"`
+		Created  string    `json:"created"`
+		Author   *Person   `jsonapi:"relation,author"`
+		Comments []*Person `jsonapi:"relation,commenters"`
+	}
+
+	t.Run("should accept parameters matching the model", func(t *testing.T) {
+		query, err := url.ParseQuery("include=author.company,commenters&fields[articles]=title,author&fields[companies]=name&sort=-created,author.name")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out, err := ValidateQuery(query, reflect.TypeOf(new(Article)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !out.HasInclude("author.company") {
+			t.Errorf("expected author.company to be included")
+		}
+	})
+
+	t.Run("should report every violation as 400 error with offending parameter", func(t *testing.T) {
+		query := url.Values{
+			"include":         {"authr,author.company.owner"},
+			"fields[people]":  {"name,passwordHash"},
+			"fields[unknown]": {"name"},
+			"sort":            {"passwordHash,author"},
+		}
+
+		_, err := ValidateQuery(query, reflect.TypeOf(Article{}))
+		if err == nil {
+			t.Fatal("expected error")
+		}
+
+		var payload *ErrorsPayload
+		if !errors.As(err, &payload) {
+			t.Fatalf("expected *ErrorsPayload, got %T", err)
+		}
+
+		counts := map[string]int{}
+		for _, e := range payload.Errors {
+			if e.Status != "400" {
+				t.Errorf("unexpected status %s", e.Status)
+			}
+			counts[e.Source["parameter"].(string)]++
+		}
+
+		expected := map[string]int{"include": 2, "fields[people]": 1, "fields[unknown]": 1, "sort": 2}
+		if !reflect.DeepEqual(counts, expected) {
+			t.Errorf("expected %+v, got %+v", expected, counts)
+		}
+	})
+}
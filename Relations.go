@@ -0,0 +1,396 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// RelationState tells apart relationships that were not loaded from empty ones.
+type RelationState int
+
+const (
+	//RelationAbsent relationship is not loaded, it is omitted or emitted with links and meta only
+	RelationAbsent RelationState = iota
+	//RelationNull relationship is loaded and empty
+	RelationNull
+	//RelationPresent relationship is loaded and holds Value
+	RelationPresent
+)
+
+// Ref wraps a relationship field to carry its state along with relationship level links and meta.
+// T is anything a relation field could be declared as: a resource struct, a pointer or a slice of those, or an id.
+// The zero value is an absent relationship.
+type Ref[T any] struct {
+	Value T
+	State RelationState
+	Links map[string]interface{}
+	Meta  map[string]interface{}
+}
+
+func NewRef[T any](value T) Ref[T] {
+	return Ref[T]{Value: value, State: RelationPresent}
+}
+
+func NullRef[T any]() Ref[T] {
+	return Ref[T]{State: RelationNull}
+}
+
+func (r Ref[T]) isRef() {}
+
+type relationRef interface {
+	isRef()
+}
+
+var relationRefType = reflect.TypeOf((*relationRef)(nil)).Elem()
+
+func isRefType(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t.Implements(relationRefType)
+}
+
+// relationValueType unwraps Ref to the type of the relationship value.
+func relationValueType(t reflect.Type) reflect.Type {
+	if isRefType(t) {
+		field, _ := t.FieldByName("Value")
+		return field.Type
+	}
+	return t
+}
+
+// relationTag holds the options of jsonapi:"relation,name,options..." tag.
+type relationTag struct {
+	//resourceType is the declared type of the related resources, required on foreign key fields
+	resourceType string
+	//typeOverride is set with type= option and replaces the type of related structs in linkage and included
+	typeOverride string
+	//omitEmpty treats nil pointers, nil slices and zero ids as not loaded relationships
+	omitEmpty bool
+	//include puts related resources into included even if they only hold an id
+	include bool
+}
+
+// parseRelationTag reads the options following the relationship name. Foreign key fields may declare the related
+// resource type as the first bare option, e.g. jsonapi:"relation,author,people,omitempty", anything else has to be
+// a known option.
+func parseRelationTag(field reflect.StructField) (relationTag, error) {
+	out := relationTag{}
+
+	parts := strings.Split(field.Tag.Get("jsonapi"), ",")
+	if len(parts) < 3 {
+		return out, nil
+	}
+	for i, option := range parts[2:] {
+		switch option {
+		case "":
+		case "omitempty":
+			out.omitEmpty = true
+		case "include":
+			out.include = true
+		default:
+			if resourceType, ok := strings.CutPrefix(option, "type="); ok && resourceType != "" {
+				out.typeOverride = resourceType
+				out.resourceType = resourceType
+				continue
+			}
+			if i != 0 || !isForeignKeyType(field.Type) {
+				return out, fmt.Errorf("unknown option %q in relation %s tag", option, field.Name)
+			}
+			out.resourceType = option
+		}
+	}
+	return out, nil
+}
+
+// isForeignKeyType reports whether the relationship field holds ids of the related resources instead of the resources.
+// Anything that is not a struct with a primary key or an interface, or a pointer or a slice of those, is treated as
+// a foreign key. Interfaces hold resources of any type, each marshaled according to its concrete type.
+func isForeignKeyType(t reflect.Type) bool {
+	t = relationValueType(t)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+	}
+	if t.Kind() == reflect.Interface {
+		return false
+	}
+	return t.Kind() != reflect.Struct || !hasPrimaryField(t)
+}
+
+// isResourceValue reports whether the value held by an interface relationship is a struct with a primary key, or a pointer to one.
+func isResourceValue(v reflect.Value) bool {
+	t := v.Type()
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && hasPrimaryField(t)
+}
+
+func hasPrimaryField(t reflect.Type) bool {
+	for _, field := range resourceFields(t) {
+		if getJsonapiFieldType(field) == "primary" {
+			return true
+		}
+	}
+	return false
+}
+
+func foreignKeyResourceType(field reflect.StructField) (string, error) {
+	tag, err := parseRelationTag(field)
+	if err != nil {
+		return "", err
+	}
+	resourceType := tag.resourceType
+	if resourceType == "" {
+		return "", fmt.Errorf("relation %s holds ids and has to declare the related resource type, e.g. jsonapi:\"relation,%s,people\"", field.Name, getAttributeName(field))
+	}
+	return resourceType, nil
+}
+
+// prepareForeignKeyNode builds resource identifier linkage from id values. Nil pointers and zero values produce null linkage.
+func prepareForeignKeyNode(v reflect.Value, resourceType string, opts *options) (interface{}, error) {
+	switch v.Kind() {
+	case reflect.Slice:
+		embed := make([]interface{}, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			next, err := prepareForeignKeyNode(v.Index(i), resourceType, opts)
+			if err != nil {
+				return nil, err
+			}
+			if next == nil {
+				return nil, fmt.Errorf("empty id at index %d of %s relationship", i, resourceType)
+			}
+			embed[i] = next
+		}
+		return embed, nil
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil, nil
+		}
+	default:
+		if v.IsZero() {
+			return nil, nil
+		}
+	}
+
+	id, err := formatIDWithCodec(v, opts)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"type": resourceType,
+		"id":   id,
+	}, nil
+}
+
+// unmarshalForeignKey sets ids from resource identifier linkage checking the linkage type against the declared one.
+func unmarshalForeignKey(fieldVal reflect.Value, data interface{}, resourceType string, opts *options) error {
+	if data == nil {
+		fieldVal.Set(reflect.Zero(fieldVal.Type()))
+		return nil
+	}
+
+	if fieldVal.Kind() == reflect.Slice {
+		list, ok := data.([]interface{})
+		if !ok {
+			return errors.New("invalid relationship data structure - expecting a slice of relationships")
+		}
+		out := reflect.MakeSlice(fieldVal.Type(), len(list), len(list))
+		for i, item := range list {
+			if err := unmarshalForeignKey(out.Index(i), item, resourceType, opts); err != nil {
+				return err
+			}
+		}
+		fieldVal.Set(out)
+		return nil
+	}
+
+	linkage, ok := data.(map[string]interface{})
+	if !ok {
+		return errors.New("invalid relationship data structure")
+	}
+	if linkage["type"] != resourceType {
+		return fmt.Errorf("relationship type does not match declared type, expect %s, got %v", resourceType, linkage["type"])
+	}
+	return setIDValue(fieldVal, linkage["id"], opts)
+}
+
+// setForeignKeyValue sets ids received without linkage, e.g. as patch values.
+func setForeignKeyValue(fieldVal reflect.Value, value interface{}, opts *options) error {
+	if value == nil {
+		fieldVal.Set(reflect.Zero(fieldVal.Type()))
+		return nil
+	}
+
+	if fieldVal.Kind() == reflect.Slice {
+		list, ok := value.([]interface{})
+		if !ok {
+			return errors.New("invalid patch operation - cannot target slice with non-slice value with replace")
+		}
+		out := reflect.MakeSlice(fieldVal.Type(), len(list), len(list))
+		for i, item := range list {
+			if err := setIDValue(out.Index(i), item, opts); err != nil {
+				return err
+			}
+		}
+		fieldVal.Set(out)
+		return nil
+	}
+
+	return setIDValue(fieldVal, value, opts)
+}
+
+// prepareRelationship builds the relationship object of a relation field. Nil result means that the relationship is omitted.
+func prepareRelationship(field reflect.StructField, fieldVal reflect.Value, refcache *includesCache, opts *options) (map[string]interface{}, error) {
+	tag, err := parseRelationTag(field)
+	if err != nil {
+		return nil, err
+	}
+	relationship := map[string]interface{}{}
+
+	if isRefType(fieldVal.Type()) {
+		if links := fieldVal.FieldByName("Links"); links.Len() > 0 {
+			relationship["links"] = links.Interface()
+		}
+		if meta := fieldVal.FieldByName("Meta"); meta.Len() > 0 {
+			relationship["meta"] = meta.Interface()
+		}
+
+		switch RelationState(fieldVal.FieldByName("State").Int()) {
+		case RelationAbsent:
+			if len(relationship) == 0 {
+				return nil, nil
+			}
+			return relationship, nil
+		case RelationNull:
+			relationship["data"] = nil
+			return relationship, nil
+		}
+		fieldVal = fieldVal.FieldByName("Value")
+	} else if tag.omitEmpty && isRelationEmpty(fieldVal) {
+		return nil, nil
+	}
+
+	if isForeignKeyType(field.Type) {
+		resourceType, err := foreignKeyResourceType(field)
+		if err != nil {
+			return nil, err
+		}
+		data, err := prepareForeignKeyNode(fieldVal, resourceType, opts)
+		if err != nil {
+			return nil, err
+		}
+		relationship["data"] = data
+		return relationship, nil
+	}
+
+	data, err := prepareRelationshipNode(fieldVal, tag, refcache, opts)
+	if err != nil {
+		return nil, err
+	}
+	relationship["data"] = data
+	return relationship, nil
+}
+
+// Includer is implemented by resources deciding themselves whether they go into included when they only hold an id.
+type Includer interface {
+	JSONAPIInclude() bool
+}
+
+func includesPhantom(v reflect.Value) bool {
+	if v.CanAddr() {
+		if includer, ok := v.Addr().Interface().(Includer); ok {
+			return includer.JSONAPIInclude()
+		}
+	}
+	if includer, ok := v.Interface().(Includer); ok {
+		return includer.JSONAPIInclude()
+	}
+	return false
+}
+
+// isPhantom reports whether the resource has no non-zero attributes and relationships, i.e. it only identifies
+// the related resource.
+func isPhantom(v reflect.Value) bool {
+	for _, field := range resourceFields(v.Type()) {
+		if getEncodedFieldName(field) == "-" {
+			continue
+		}
+		switch getJsonapiFieldType(field) {
+		case "", "attr", "relation":
+		default:
+			continue
+		}
+		if val, ok := fieldByIndex(v, field.Index); ok && !val.IsZero() {
+			return false
+		}
+	}
+	return true
+}
+
+func isRelationEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Interface:
+		return v.IsNil()
+	default:
+		return v.IsZero()
+	}
+}
+
+// unmarshalRelationship fills in the relation field from the relationship object. Ref fields receive the state of
+// the relationship as well, other fields are left untouched when the relationship has no data.
+func unmarshalRelationship(fieldType reflect.StructField, fieldVal reflect.Value, relationship map[string]interface{}, graph *unmarshalGraph, opts *options) error {
+	data, hasData := relationship["data"]
+
+	if isRefType(fieldVal.Type()) {
+		state := RelationAbsent
+		switch {
+		case !hasData:
+		case data == nil:
+			state = RelationNull
+		default:
+			state = RelationPresent
+		}
+
+		ref := reflect.New(fieldVal.Type()).Elem()
+		ref.FieldByName("State").SetInt(int64(state))
+		if links, ok := relationship["links"].(map[string]interface{}); ok {
+			ref.FieldByName("Links").Set(reflect.ValueOf(normalizeNumbers(links)))
+		}
+		if meta, ok := relationship["meta"].(map[string]interface{}); ok {
+			ref.FieldByName("Meta").Set(reflect.ValueOf(normalizeNumbers(meta)))
+		}
+		if state == RelationPresent {
+			if err := unmarshalRelationshipData(fieldType, ref.FieldByName("Value"), data, graph, opts); err != nil {
+				return err
+			}
+		}
+		fieldVal.Set(ref)
+		return nil
+	}
+
+	if !hasData {
+		return nil
+	}
+	return unmarshalRelationshipData(fieldType, fieldVal, data, graph, opts)
+}
+
+func unmarshalRelationshipData(fieldType reflect.StructField, fieldVal reflect.Value, data interface{}, graph *unmarshalGraph, opts *options) error {
+	if isForeignKeyType(fieldType.Type) {
+		resourceType, err := foreignKeyResourceType(fieldType)
+		if err != nil {
+			return err
+		}
+		return unmarshalForeignKey(fieldVal, data, resourceType, opts)
+	}
+	tag, err := parseRelationTag(fieldType)
+	if err != nil {
+		return err
+	}
+	return unmarshalSingleRelationship(fieldVal, data, tag.typeOverride, graph, opts)
+}
@@ -0,0 +1,470 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestForeignKeyRelations(t *testing.T) {
+
+	type SUT struct {
+		ID          string   `jsonapi:"primary,articles"`
+		AuthorID    string   `jsonapi:"relation,author,people"`
+		EditorID    *int64   `jsonapi:"relation,editor,people"`
+		ReviewerID  string   `jsonapi:"relation,reviewer,people"`
+		CategoryIDs []string `jsonapi:"relation,categories,categories"`
+	}
+
+	t.Run("should marshal id fields as resource identifier linkage", func(t *testing.T) {
+		editor := int64(42)
+		input := SUT{
+			ID:          "1",
+			AuthorID:    "9",
+			EditorID:    &editor,
+			CategoryIDs: []string{"a", "b"},
+		}
+
+		raw, err := Marshal(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		check := map[string]interface{}{}
+		if err := json.Unmarshal(raw, &check); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok := check["included"]; ok {
+			t.Fatal("expected no included resources")
+		}
+
+		rels := check["data"].(map[string]interface{})["relationships"].(map[string]interface{})
+		expected := map[string]interface{}{
+			"author":   map[string]interface{}{"data": map[string]interface{}{"type": "people", "id": "9"}},
+			"editor":   map[string]interface{}{"data": map[string]interface{}{"type": "people", "id": "42"}},
+			"reviewer": map[string]interface{}{"data": nil},
+			"categories": map[string]interface{}{"data": []interface{}{
+				map[string]interface{}{"type": "categories", "id": "a"},
+				map[string]interface{}{"type": "categories", "id": "b"},
+			}},
+		}
+		if !reflect.DeepEqual(rels, expected) {
+			t.Errorf("expected %+v, got %+v", expected, rels)
+		}
+	})
+
+	t.Run("should unmarshal linkage ids into id fields", func(t *testing.T) {
+		raw := []byte(`{"data":{"id":"1","type":"articles","relationships":{
+			"author":{"data":{"type":"people","id":"9"}},
+			"editor":{"data":{"type":"people","id":"42"}},
+			"reviewer":{"data":null},
+			"categories":{"data":[{"type":"categories","id":"a"},{"type":"categories","id":"b"}]}
+		}}}`)
+
+		out := SUT{ReviewerID: "stale"}
+		if err := Unmarshal(raw, &out); err != nil {
+			t.Fatal(err)
+		}
+
+		if out.AuthorID != "9" {
+			t.Fatal("unexpected author id", out.AuthorID)
+		}
+		if out.EditorID == nil || *out.EditorID != 42 {
+			t.Fatal("unexpected editor id", out.EditorID)
+		}
+		if out.ReviewerID != "" {
+			t.Fatal("expected reviewer id to be reset by null linkage", out.ReviewerID)
+		}
+		if !reflect.DeepEqual(out.CategoryIDs, []string{"a", "b"}) {
+			t.Fatal("unexpected category ids", out.CategoryIDs)
+		}
+	})
+
+	t.Run("should reject linkage of a different type", func(t *testing.T) {
+		raw := []byte(`{"data":{"id":"1","type":"articles","relationships":{
+			"author":{"data":{"type":"robots","id":"9"}}
+		}}}`)
+
+		if err := Unmarshal(raw, &SUT{}); err == nil {
+			t.Fatal("expected type mismatch error")
+		}
+	})
+
+	t.Run("should require declared type on id fields", func(t *testing.T) {
+		type Undeclared struct {
+			ID       string `jsonapi:"primary,articles"`
+			AuthorID string `jsonapi:"relation,author"`
+		}
+
+		if _, err := Marshal(Undeclared{ID: "1", AuthorID: "9"}); err == nil {
+			t.Fatal("expected marshal error")
+		}
+
+		raw := []byte(`{"data":{"id":"1","type":"articles","relationships":{"author":{"data":{"type":"people","id":"9"}}}}}`)
+		if err := Unmarshal(raw, &Undeclared{}); err == nil {
+			t.Fatal("expected unmarshal error")
+		}
+	})
+
+	t.Run("should reject unknown relation options", func(t *testing.T) {
+		type Person struct {
+			ID string `jsonapi:"primary,people"`
+		}
+		type Typo struct {
+			ID       string  `jsonapi:"primary,articles"`
+			AuthorID string  `jsonapi:"relation,author,people,omitemtpy"`
+			Editor   *Person `jsonapi:"relation,editor"`
+		}
+		type StructTypo struct {
+			ID     string  `jsonapi:"primary,articles"`
+			Editor *Person `jsonapi:"relation,editor,omitemtpy"`
+		}
+		type Valid struct {
+			ID       string  `jsonapi:"primary,articles"`
+			AuthorID string  `jsonapi:"relation,author,people,omitempty"`
+			EditorID string  `jsonapi:"relation,editor,type=people"`
+			Reviewer *Person `jsonapi:"relation,reviewer,omitempty,include"`
+		}
+
+		for _, model := range []interface{}{&Typo{ID: "1"}, &StructTypo{ID: "1"}} {
+			if _, err := Marshal(model); err == nil || !strings.Contains(err.Error(), `unknown option "omitemtpy"`) {
+				t.Errorf("expected unknown option error for %T, got %v", model, err)
+			}
+		}
+
+		raw := []byte(`{"data":{"id":"1","type":"articles","relationships":{"editor":{"data":{"type":"people","id":"9"}}}}}`)
+		if err := Unmarshal(raw, &StructTypo{}); err == nil {
+			t.Fatal("expected unmarshal error")
+		}
+
+		if _, err := Marshal(&Valid{ID: "1", AuthorID: "2", EditorID: "3"}); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("should unmarshal patches targeting id fields", func(t *testing.T) {
+		patches, err := UnmarshalPatches([]byte(`[
+			{"op":"replace","path":"/author","value":"10"},
+			{"op":"replace","path":"/editor","value":"43"},
+			{"op":"replace","path":"/categories","value":["c"]},
+			{"op":"add","path":"/categories","value":"d"}
+		]`), reflect.TypeOf(&SUT{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if patches[0].Value != "10" {
+			t.Fatal("unexpected author patch value", patches[0].Value)
+		}
+		if editor, ok := patches[1].Value.(*int64); !ok || *editor != 43 {
+			t.Fatal("unexpected editor patch value", patches[1].Value)
+		}
+		if !reflect.DeepEqual(patches[2].Value, []string{"c"}) {
+			t.Fatal("unexpected categories patch value", patches[2].Value)
+		}
+		if patches[3].Value != "d" {
+			t.Fatal("unexpected categories add value", patches[3].Value)
+		}
+	})
+}
+
+func TestRelationState(t *testing.T) {
+
+	type Person struct {
+		ID   string `jsonapi:"primary,people"`
+		Name string `jsonapi:"attr,name"`
+	}
+
+	type SUT struct {
+		ID       string        `jsonapi:"primary,articles"`
+		Author   Ref[*Person]  `jsonapi:"relation,author"`
+		Editor   Ref[*Person]  `jsonapi:"relation,editor"`
+		Comments Ref[[]string] `jsonapi:"relation,comments,comments"`
+		Reviewer *Person       `jsonapi:"relation,reviewer,omitempty"`
+		Tags     []*Person     `jsonapi:"relation,tags,omitempty"`
+		Owner    string        `jsonapi:"relation,owner,people,omitempty"`
+		Series   Ref[Person]   `jsonapi:"relation,series"`
+		Previous Ref[*Person]  `jsonapi:"relation,previous"`
+	}
+
+	marshalRelationships := func(t *testing.T, input SUT) (map[string]interface{}, map[string]interface{}) {
+		raw, err := Marshal(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check := map[string]interface{}{}
+		if err := json.Unmarshal(raw, &check); err != nil {
+			t.Fatal(err)
+		}
+		return check["data"].(map[string]interface{})["relationships"].(map[string]interface{}), check
+	}
+
+	t.Run("should omit not loaded relationships", func(t *testing.T) {
+		rels, _ := marshalRelationships(t, SUT{ID: "1"})
+
+		if len(rels) != 0 {
+			t.Fatal("expected all relationships to be omitted", rels)
+		}
+	})
+
+	t.Run("should emit links and meta only for not loaded relationships with links", func(t *testing.T) {
+		rels, _ := marshalRelationships(t, SUT{
+			ID: "1",
+			Author: Ref[*Person]{
+				Links: map[string]interface{}{"related": "/articles/1/author"},
+				Meta:  map[string]interface{}{"count": 1},
+			},
+		})
+
+		expected := map[string]interface{}{
+			"author": map[string]interface{}{
+				"links": map[string]interface{}{"related": "/articles/1/author"},
+				"meta":  map[string]interface{}{"count": float64(1)},
+			},
+		}
+		if !reflect.DeepEqual(rels, expected) {
+			t.Errorf("expected %+v, got %+v", expected, rels)
+		}
+	})
+
+	t.Run("should emit null and loaded relationships", func(t *testing.T) {
+		rels, check := marshalRelationships(t, SUT{
+			ID:       "1",
+			Author:   NewRef(&Person{ID: "2", Name: "a"}),
+			Editor:   NullRef[*Person](),
+			Comments: NewRef([]string{"5"}),
+			Reviewer: &Person{ID: "3", Name: "r"},
+			Tags:     []*Person{},
+			Owner:    "4",
+		})
+
+		expected := map[string]interface{}{
+			"author":   map[string]interface{}{"data": map[string]interface{}{"type": "people", "id": "2"}},
+			"editor":   map[string]interface{}{"data": nil},
+			"comments": map[string]interface{}{"data": []interface{}{map[string]interface{}{"type": "comments", "id": "5"}}},
+			"reviewer": map[string]interface{}{"data": map[string]interface{}{"type": "people", "id": "3"}},
+			"tags":     map[string]interface{}{"data": []interface{}{}},
+			"owner":    map[string]interface{}{"data": map[string]interface{}{"type": "people", "id": "4"}},
+		}
+		if !reflect.DeepEqual(rels, expected) {
+			t.Errorf("expected %+v, got %+v", expected, rels)
+		}
+		if len(check["included"].([]interface{})) != 2 {
+			t.Fatal("expected loaded resources to be included", check["included"])
+		}
+	})
+
+	t.Run("should report relationship state on unmarshal", func(t *testing.T) {
+		raw := []byte(`{"data":{"id":"1","type":"articles","relationships":{
+			"author":{"data":{"type":"people","id":"2"}},
+			"editor":{"data":null},
+			"comments":{"links":{"related":"/articles/1/comments"},"meta":{"count":3}},
+			"series":{"data":{"type":"people","id":"6"}}
+		}},"included":[{"type":"people","id":"2","attributes":{"name":"a"}}]}`)
+
+		out := SUT{}
+		if err := Unmarshal(raw, &out); err != nil {
+			t.Fatal(err)
+		}
+
+		if out.Author.State != RelationPresent || out.Author.Value == nil || out.Author.Value.Name != "a" {
+			t.Fatal("unexpected author", out.Author)
+		}
+		if out.Editor.State != RelationNull || out.Editor.Value != nil {
+			t.Fatal("unexpected editor", out.Editor)
+		}
+		if out.Comments.State != RelationAbsent || out.Comments.Value != nil {
+			t.Fatal("unexpected comments", out.Comments)
+		}
+		if out.Comments.Links["related"] != "/articles/1/comments" || out.Comments.Meta["count"] != float64(3) {
+			t.Fatal("unexpected comments links and meta", out.Comments)
+		}
+		if out.Series.State != RelationPresent || out.Series.Value.ID != "6" {
+			t.Fatal("unexpected series", out.Series)
+		}
+		if out.Previous.State != RelationAbsent {
+			t.Fatal("unexpected previous", out.Previous)
+		}
+	})
+
+	t.Run("should keep plain fields untouched by relationships without data", func(t *testing.T) {
+		raw := []byte(`{"data":{"id":"1","type":"articles","relationships":{
+			"reviewer":{"links":{"related":"/articles/1/reviewer"}}
+		}}}`)
+
+		out := SUT{Reviewer: &Person{ID: "3"}}
+		if err := Unmarshal(raw, &out); err != nil {
+			t.Fatal(err)
+		}
+		if out.Reviewer == nil || out.Reviewer.ID != "3" {
+			t.Fatal("unexpected reviewer", out.Reviewer)
+		}
+	})
+
+	t.Run("should unmarshal patches targeting Ref values", func(t *testing.T) {
+		patches, err := UnmarshalPatches([]byte(`[{"op":"replace","path":"/comments","value":["7"]}]`), reflect.TypeOf(&SUT{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(patches[0].Value, []string{"7"}) {
+			t.Fatal("unexpected patch value", patches[0].Value)
+		}
+	})
+}
+
+type includedPerson struct {
+	ID string `jsonapi:"primary,people"`
+}
+
+func (p *includedPerson) JSONAPIInclude() bool {
+	return true
+}
+
+func TestPhantomRelations(t *testing.T) {
+
+	type Person struct {
+		ID   string `jsonapi:"primary,people"`
+		Name string `jsonapi:"attr,name"`
+	}
+
+	type SUT struct {
+		ID       string            `jsonapi:"primary,articles"`
+		Author   *Person           `jsonapi:"relation,author"`
+		Editor   *Person           `jsonapi:"relation,editor,include"`
+		Reviewer *includedPerson   `jsonapi:"relation,reviewer"`
+		Tags     []*Person         `jsonapi:"relation,tags"`
+		Owners   []*includedPerson `jsonapi:"relation,owners"`
+	}
+
+	includedIDs := func(t *testing.T, input SUT) (map[string]bool, map[string]interface{}) {
+		raw, err := Marshal(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check := map[string]interface{}{}
+		if err := json.Unmarshal(raw, &check); err != nil {
+			t.Fatal(err)
+		}
+
+		out := map[string]bool{}
+		included, _ := check["included"].([]interface{})
+		for _, v := range included {
+			out[v.(map[string]interface{})["id"].(string)] = true
+		}
+		return out, check["data"].(map[string]interface{})["relationships"].(map[string]interface{})
+	}
+
+	t.Run("should link resources holding only an id without including them", func(t *testing.T) {
+		ids, rels := includedIDs(t, SUT{
+			ID:     "1",
+			Author: &Person{ID: "2"},
+			Tags:   []*Person{{ID: "3"}, {ID: "4", Name: "d"}},
+		})
+
+		if !reflect.DeepEqual(ids, map[string]bool{"4": true}) {
+			t.Fatal("unexpected included resources", ids)
+		}
+		expected := map[string]interface{}{"type": "people", "id": "2"}
+		if author := rels["author"].(map[string]interface{})["data"]; !reflect.DeepEqual(author, expected) {
+			t.Fatal("expected phantom resource to be linked", author)
+		}
+	})
+
+	t.Run("should include phantom resources on demand", func(t *testing.T) {
+		ids, _ := includedIDs(t, SUT{
+			ID:       "1",
+			Editor:   &Person{ID: "2"},
+			Reviewer: &includedPerson{ID: "3"},
+			Owners:   []*includedPerson{{ID: "4"}},
+		})
+
+		if !reflect.DeepEqual(ids, map[string]bool{"2": true, "3": true, "4": true}) {
+			t.Fatal("unexpected included resources", ids)
+		}
+	})
+}
+
+func TestRelationTypeOverride(t *testing.T) {
+
+	type User struct {
+		ID   string `jsonapi:"primary,users"`
+		Name string `jsonapi:"attr,name"`
+	}
+
+	type Article struct {
+		ID        string  `jsonapi:"primary,articles"`
+		Author    *User   `jsonapi:"relation,author,type=authors"`
+		Editor    *User   `jsonapi:"relation,editor,type=editors"`
+		Reviewers []*User `jsonapi:"relation,reviewers,type=reviewers,omitempty"`
+		Owner     *User   `jsonapi:"relation,owner"`
+		PublishID string  `jsonapi:"relation,publisher,type=publishers"`
+	}
+
+	user := &User{ID: "1", Name: "a"}
+	input := &Article{ID: "1", Author: user, Editor: user, Reviewers: []*User{user}, Owner: user, PublishID: "2"}
+
+	t.Run("should emit overridden types in linkage and included", func(t *testing.T) {
+		raw, err := Marshal(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check := map[string]interface{}{}
+		if err := json.Unmarshal(raw, &check); err != nil {
+			t.Fatal(err)
+		}
+
+		rels := check["data"].(map[string]interface{})["relationships"].(map[string]interface{})
+		expected := map[string]interface{}{
+			"author":    map[string]interface{}{"data": map[string]interface{}{"type": "authors", "id": "1"}},
+			"editor":    map[string]interface{}{"data": map[string]interface{}{"type": "editors", "id": "1"}},
+			"reviewers": map[string]interface{}{"data": []interface{}{map[string]interface{}{"type": "reviewers", "id": "1"}}},
+			"owner":     map[string]interface{}{"data": map[string]interface{}{"type": "users", "id": "1"}},
+			"publisher": map[string]interface{}{"data": map[string]interface{}{"type": "publishers", "id": "2"}},
+		}
+		if !reflect.DeepEqual(rels, expected) {
+			t.Errorf("expected %+v, got %+v", expected, rels)
+		}
+
+		types := map[string]bool{}
+		for _, include := range check["included"].([]interface{}) {
+			types[include.(map[string]interface{})["type"].(string)] = true
+		}
+		if !reflect.DeepEqual(types, map[string]bool{"authors": true, "editors": true, "reviewers": true, "users": true}) {
+			t.Fatal("unexpected included types", types)
+		}
+	})
+
+	t.Run("should accept overridden types on unmarshal", func(t *testing.T) {
+		raw, err := Marshal(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out := Article{}
+		if err := Unmarshal(raw, &out); err != nil {
+			t.Fatal(err)
+		}
+		if out.Author == nil || out.Author.Name != "a" || out.Editor == nil || out.Editor.Name != "a" {
+			t.Fatal("unexpected users", out.Author, out.Editor)
+		}
+		if len(out.Reviewers) != 1 || out.Reviewers[0].Name != "a" || out.PublishID != "2" {
+			t.Fatal("unexpected reviewers and publisher", out.Reviewers, out.PublishID)
+		}
+	})
+
+	t.Run("should reject the type of the primary tag on overridden relations", func(t *testing.T) {
+		raw := []byte(`{"data":{"id":"1","type":"articles","relationships":{"author":{"data":{"type":"users","id":"1"}}}}}`)
+		if err := Unmarshal(raw, &Article{}); err == nil {
+			t.Fatal("expected type mismatch error")
+		}
+	})
+
+	t.Run("should validate sparse fieldsets of overridden types", func(t *testing.T) {
+		if _, err := ValidateQuery(url.Values{"fields[editors]": {"name"}}, reflect.TypeOf(Article{})); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
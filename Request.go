@@ -0,0 +1,82 @@
+package jsonapi
+
+import (
+	"reflect"
+)
+
+// WithRequestDocument marshals documents sent by clients creating or updating resources. Zero ids are omitted,
+// resources without id are identified by their lid field if there's one, empty attributes and relationships objects
+// are omitted, and included resources are dropped unless WithSideposting is set as well.
+func WithRequestDocument() Option {
+	return func(o *options) {
+		o.requestDocument = true
+	}
+}
+
+// WithSideposting keeps included resources in request documents, see WithRequestDocument.
+func WithSideposting() Option {
+	return func(o *options) {
+		o.sideposting = true
+	}
+}
+
+// getResourceLID returns the local id of the resource from the field tagged jsonapi:"lid", if any.
+func getResourceLID(inVal reflect.Value, inType reflect.Type) string {
+	for _, field := range resourceFields(inType) {
+		if getJsonapiFieldType(field) != "lid" {
+			continue
+		}
+		lidField, ok := fieldByIndex(inVal, field.Index)
+		if !ok || lidField.Kind() != reflect.String {
+			return ""
+		}
+		return lidField.String()
+	}
+	return ""
+}
+
+// hasZeroID reports whether the primary key of the resource is not set: a zero value, a nil pointer, or a nil
+// embedded struct holding the key. Emptiness is decided on the field, numeric ids are formatted as "0" otherwise.
+func hasZeroID(inVal reflect.Value) bool {
+	for _, field := range resourceFields(inVal.Type()) {
+		if getJsonapiFieldType(field) == "primary" {
+			idField, ok := fieldByIndex(inVal, field.Index)
+			return !ok || idField.IsZero()
+		}
+	}
+	return true
+}
+
+// setIdentity sets id and lid members of a resource object or a resource identifier.
+func setIdentity(node map[string]interface{}, inVal reflect.Value, id string, opts *options) {
+	empty := id == "" || hasZeroID(inVal)
+	if !empty || !opts.requestDocument {
+		node["id"] = id
+	}
+	if lid := getResourceLID(inVal, inVal.Type()); lid != "" && empty {
+		node["lid"] = lid
+	}
+}
+
+// compactRequestDocument drops empty attributes and relationships objects of primary and included resources.
+func compactRequestDocument(doc map[string]interface{}) {
+	nodes := make([]map[string]interface{}, 0)
+	switch data := doc["data"].(type) {
+	case map[string]interface{}:
+		nodes = append(nodes, data)
+	case []map[string]interface{}:
+		nodes = append(nodes, data...)
+	}
+	included, _ := doc["included"].([]interface{})
+	for _, include := range included {
+		nodes = append(nodes, include.(map[string]interface{}))
+	}
+
+	for _, node := range nodes {
+		for _, member := range []string{"attributes", "relationships"} {
+			if m, ok := node[member].(map[string]interface{}); ok && len(m) == 0 {
+				delete(node, member)
+			}
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestRequestDocument(t *testing.T) {
+
+	type Person struct {
+		ID   string `jsonapi:"primary,people"`
+		LID  string `jsonapi:"lid"`
+		Name string `jsonapi:"attr,name"`
+	}
+
+	type Article struct {
+		ID     string  `jsonapi:"primary,articles"`
+		Title  string  `jsonapi:"attr,title,omitempty"`
+		Author *Person `jsonapi:"relation,author,omitempty"`
+	}
+
+	marshal := func(t *testing.T, in interface{}, opts ...Option) map[string]interface{} {
+		raw, err := Marshal(in, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check := map[string]interface{}{}
+		if err := json.Unmarshal(raw, &check); err != nil {
+			t.Fatal(err)
+		}
+		return check
+	}
+
+	t.Run("should omit zero id and empty members", func(t *testing.T) {
+		check := marshal(t, &Article{}, WithRequestDocument())
+
+		expected := map[string]interface{}{"data": map[string]interface{}{"type": "articles"}}
+		if !reflect.DeepEqual(check, expected) {
+			t.Errorf("expected %+v, got %+v", expected, check)
+		}
+	})
+
+	t.Run("should omit zero numeric ids and keep set ones", func(t *testing.T) {
+		type Counter struct {
+			ID int `jsonapi:"primary,counters"`
+		}
+		type Pointer struct {
+			ID *uint `jsonapi:"primary,pointers"`
+		}
+
+		zero := uint(0)
+		cases := []struct {
+			input    interface{}
+			expected map[string]interface{}
+		}{
+			{&Counter{}, map[string]interface{}{"type": "counters"}},
+			{&Counter{ID: 7}, map[string]interface{}{"type": "counters", "id": "7"}},
+			{&Pointer{}, map[string]interface{}{"type": "pointers"}},
+			{&Pointer{ID: &zero}, map[string]interface{}{"type": "pointers", "id": "0"}},
+		}
+
+		for _, c := range cases {
+			check := marshal(t, c.input, WithRequestDocument())
+			if !reflect.DeepEqual(check["data"], c.expected) {
+				t.Errorf("expected %+v, got %+v", c.expected, check["data"])
+			}
+		}
+	})
+
+	t.Run("should keep response documents unchanged", func(t *testing.T) {
+		check := marshal(t, &Article{})
+
+		expected := map[string]interface{}{"data": map[string]interface{}{
+			"type":          "articles",
+			"id":            "",
+			"attributes":    map[string]interface{}{},
+			"relationships": map[string]interface{}{},
+		}}
+		if !reflect.DeepEqual(check, expected) {
+			t.Errorf("expected %+v, got %+v", expected, check)
+		}
+	})
+
+	t.Run("should drop included resources unless sideposting", func(t *testing.T) {
+		input := []*Article{{Title: "t", Author: &Person{ID: "2", Name: "a"}}}
+
+		check := marshal(t, input, WithRequestDocument())
+		if _, ok := check["included"]; ok {
+			t.Fatal("expected no included resources", check["included"])
+		}
+		author := check["data"].([]interface{})[0].(map[string]interface{})["relationships"].(map[string]interface{})["author"]
+		if !reflect.DeepEqual(author, map[string]interface{}{"data": map[string]interface{}{"type": "people", "id": "2"}}) {
+			t.Fatal("unexpected author linkage", author)
+		}
+
+		check = marshal(t, input, WithRequestDocument(), WithSideposting())
+		if len(check["included"].([]interface{})) != 1 {
+			t.Fatal("expected sideposted resources", check["included"])
+		}
+	})
+
+	t.Run("should identify new resources by lid", func(t *testing.T) {
+		check := marshal(t, &Article{Title: "t", Author: &Person{LID: "new-author", Name: "a"}}, WithRequestDocument(), WithSideposting())
+
+		author := check["data"].(map[string]interface{})["relationships"].(map[string]interface{})["author"]
+		if !reflect.DeepEqual(author, map[string]interface{}{"data": map[string]interface{}{"type": "people", "lid": "new-author"}}) {
+			t.Fatal("unexpected author linkage", author)
+		}
+
+		expected := []interface{}{map[string]interface{}{
+			"type":       "people",
+			"lid":        "new-author",
+			"attributes": map[string]interface{}{"name": "a"},
+		}}
+		if !reflect.DeepEqual(check["included"], expected) {
+			t.Errorf("expected %+v, got %+v", expected, check["included"])
+		}
+	})
+
+	t.Run("should unmarshal request documents resolving lid linkage", func(t *testing.T) {
+		raw := []byte(`{
+			"data":{"type":"articles","attributes":{"title":"t"},"relationships":{"author":{"data":{"type":"people","lid":"new-author"}}}},
+			"included":[{"type":"people","lid":"new-author","attributes":{"name":"a"}}]
+		}`)
+
+		out := Article{}
+		if err := Unmarshal(raw, &out); err != nil {
+			t.Fatal(err)
+		}
+		if out.ID != "" || out.Title != "t" {
+			t.Fatal("unexpected article", out)
+		}
+		if out.Author == nil || out.Author.LID != "new-author" || out.Author.Name != "a" {
+			t.Fatal("unexpected author", out.Author)
+		}
+	})
+
+	t.Run("should not share instances between resources without id", func(t *testing.T) {
+		raw := []byte(`{"data":[{"type":"people","attributes":{"name":"a"}},{"type":"people","attributes":{"name":"b"}}]}`)
+
+		out := make([]*Person, 0)
+		if err := Unmarshal(raw, &out); err != nil {
+			t.Fatal(err)
+		}
+		if len(out) != 2 || out[0] == out[1] || out[0].Name != "a" || out[1].Name != "b" {
+			t.Fatal("unexpected people", out)
+		}
+	})
+}
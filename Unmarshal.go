@@ -2,31 +2,36 @@ package jsonapi
 
 import (
 	"encoding"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 )
 
-func UnmarshalManyAsType(payload []byte, model reflect.Type) ([]interface{}, error) {
+func UnmarshalManyAsType(payload []byte, model reflect.Type, opts ...Option) ([]interface{}, error) {
+	o := newOptions(opts)
 	raw := map[string]interface{}{}
-	err := json.Unmarshal(payload, &raw)
+	err := decodeJSON(payload, &raw)
 	if err != nil {
 		return nil, err
 	}
 
-	included, ok := raw["included"].([]interface{})
-	if !ok {
-		included = make([]interface{}, 0)
+	graph := newUnmarshalGraph(raw["data"], raw["included"])
+
+	models := make([]interface{}, 0)
+	if isNullData(raw) {
+		return models, nil
 	}
 
 	data, ok := raw["data"].([]interface{})
 	if !ok {
 		return nil, errors.New("invalid data structure")
 	}
-	models := make([]interface{}, 0)
 
 	for _, resource := range data {
 		resourceData, ok := resource.(map[string]interface{})
@@ -34,31 +39,30 @@ func UnmarshalManyAsType(payload []byte, model reflect.Type) ([]interface{}, err
 			return nil, errors.New("invalid data structure")
 		}
 
-		out := reflect.New(model.Elem()).Interface()
-
-		err = unmarshalOne(resourceData, out, included)
+		out, err := unmarshalInstance(resourceData, model.Elem(), "", graph, o)
 		if err != nil {
 			return nil, err
 		}
 
-		models = append(models, out)
+		models = append(models, out.Interface())
 	}
 
 	return models, nil
 }
 
-func UnmarshalOneAsType(payload []byte, model reflect.Type) (interface{}, error) {
+func UnmarshalOneAsType(payload []byte, model reflect.Type, opts ...Option) (interface{}, error) {
 	raw := map[string]interface{}{}
-	err := json.Unmarshal(payload, &raw)
+	err := decodeJSON(payload, &raw)
 	if err != nil {
 		return nil, err
 	}
 
-	included, ok := raw["included"].([]interface{})
-	if !ok {
-		included = make([]interface{}, 0)
+	if isNullData(raw) {
+		return nil, nil
 	}
 
+	graph := newUnmarshalGraph(raw["data"], raw["included"])
+
 	data, ok := raw["data"].(map[string]interface{})
 	if !ok {
 		return nil, errors.New("invalid data structure")
@@ -69,7 +73,7 @@ func UnmarshalOneAsType(payload []byte, model reflect.Type) (interface{}, error)
 	}
 
 	out := reflect.New(model.Elem()).Interface()
-	err = unmarshalOne(data, out, included)
+	err = unmarshalOne(data, out, graph, newOptions(opts))
 	if err != nil {
 		return nil, err
 	}
@@ -77,21 +81,33 @@ func UnmarshalOneAsType(payload []byte, model reflect.Type) (interface{}, error)
 
 }
 
-func Unmarshal(data []byte, model interface{}) error {
+func Unmarshal(data []byte, model interface{}, opts ...Option) error {
+	o := newOptions(opts)
 	raw := map[string]interface{}{}
-	err := json.Unmarshal(data, &raw)
+	err := decodeJSON(data, &raw)
 	if err != nil {
 		return err
 	}
 
-	included, ok := raw["included"].([]interface{})
-	if !ok {
-		included = []interface{}{}
+	if isNullData(raw) {
+		return unmarshalNull(model)
 	}
 
+	graph := newUnmarshalGraph(raw["data"], raw["included"])
+
 	switch raw["data"].(type) {
 	case map[string]interface{}:
-		err = unmarshalOne(raw["data"].(map[string]interface{}), model, included)
+		modelVal := reflect.ValueOf(model)
+		if modelVal.Kind() == reflect.Ptr && !modelVal.IsNil() && modelVal.Elem().Kind() == reflect.Ptr {
+			out := reflect.New(modelVal.Elem().Type().Elem())
+			if err := unmarshalOne(raw["data"].(map[string]interface{}), out.Interface(), graph, o); err != nil {
+				return err
+			}
+			modelVal.Elem().Set(out)
+			return nil
+		}
+
+		err = unmarshalOne(raw["data"].(map[string]interface{}), model, graph, o)
 		if err != nil {
 			return err
 		}
@@ -104,10 +120,11 @@ func Unmarshal(data []byte, model interface{}) error {
 			return errors.New("invalid model type")
 		}
 
+		acc := reflect.ValueOf(model).Elem()
 		if len(data) == 0 {
+			acc.Set(reflect.MakeSlice(acc.Type(), 0, 0))
 			return nil
 		}
-		acc := reflect.ValueOf(model).Elem()
 		modelVal := reflect.ValueOf(model).Elem().Type().Elem()
 		asPtr := modelVal.Kind() == reflect.Ptr
 		if asPtr {
@@ -120,17 +137,24 @@ func Unmarshal(data []byte, model interface{}) error {
 				return errors.New("invalid data structure")
 			}
 
-			out := reflect.New(modelVal).Interface()
+			if modelVal.Kind() == reflect.Interface {
+				out, err := unmarshalPolymorphic(resourceData, modelVal, graph, o)
+				if err != nil {
+					return err
+				}
+				acc.Set(reflect.Append(acc, out))
+				continue
+			}
 
-			err = unmarshalOne(resourceData, out, included)
+			out, err := unmarshalInstance(resourceData, modelVal, "", graph, o)
 			if err != nil {
 				return err
 			}
 
 			if asPtr {
-				acc.Set(reflect.Append(acc, reflect.ValueOf(out)))
+				acc.Set(reflect.Append(acc, out))
 			} else {
-				acc.Set(reflect.Append(acc, reflect.ValueOf(out).Elem()))
+				acc.Set(reflect.Append(acc, out.Elem()))
 			}
 		}
 
@@ -141,7 +165,35 @@ func Unmarshal(data []byte, model interface{}) error {
 	return nil
 }
 
-func unmarshalOne(data map[string]interface{}, model interface{}, included []interface{}) (err error) {
+// ErrNoResource is returned by Unmarshal when primary data is null and the model is a struct, which is zeroed.
+// Pointer to pointer models are set to nil instead and slices are emptied, without an error.
+var ErrNoResource = errors.New("primary data is null")
+
+func isNullData(raw map[string]interface{}) bool {
+	data, ok := raw["data"]
+	return ok && data == nil
+}
+
+func unmarshalNull(model interface{}) error {
+	modelVal := reflect.ValueOf(model)
+	if modelVal.Kind() != reflect.Ptr || modelVal.IsNil() {
+		return errors.New("invalid model type")
+	}
+
+	target := modelVal.Elem()
+	target.Set(reflect.Zero(target.Type()))
+	if target.Kind() == reflect.Struct {
+		return ErrNoResource
+	}
+	return nil
+}
+
+func unmarshalOne(data map[string]interface{}, model interface{}, graph *unmarshalGraph, opts *options) error {
+	return unmarshalOneAs(data, model, "", graph, opts)
+}
+
+// unmarshalOneAs unmarshals the resource accepting the given resource type in place of the type of the model primary tag.
+func unmarshalOneAs(data map[string]interface{}, model interface{}, acceptedType string, graph *unmarshalGraph, opts *options) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			err = fmt.Errorf("[jsonapi.unmarshalOne] recovered from: %w", r.(error))
@@ -159,26 +211,47 @@ func unmarshalOne(data map[string]interface{}, model interface{}, included []int
 	if modelType.Kind() != reflect.Struct {
 		return errors.New(fmt.Sprintf("model should be a struct to unmarshal single resource, got %s", modelType.Kind()))
 	}
+	//Registered before the fields are filled in so that references back to the resource terminate
+	graph.register(data, modelVal.Addr())
 
 	resourceType := data["type"]
+	if acceptedType != "" {
+		if resourceType != acceptedType {
+			return fmt.Errorf("relationship type does not match declared type, expect %s, got %v", acceptedType, resourceType)
+		}
+	} else if err := checkResourceTypeOf(modelVal, modelType, resourceType); err != nil {
+		return err
+	}
 	resourceID := data["id"]
 	resourceAttributes, attributesValid := data["attributes"].(map[string]interface{})
 
 	resourceRelationships, relationshipsValid := data["relationships"].(map[string]interface{})
 
-	for i := 0; i < modelType.NumField(); i++ {
-		fieldType := modelType.Field(i)
-		fieldVal := modelVal.Field(i)
+	for _, fieldType := range resourceFields(modelType) {
+		fieldVal, ok := fieldByIndex(modelVal, fieldType.Index)
+		if !ok {
+			//Promoted through a nil embedded pointer, only allocate it if there's a value to set
+			if !isFieldProvided(fieldType, resourceID, resourceAttributes, resourceRelationships) {
+				continue
+			}
+			fieldVal = fieldByIndexAlloc(modelVal, fieldType.Index)
+		}
 
-		if err := unmarshalID(fieldType, fieldVal, resourceID, resourceType.(string)); err != nil {
+		if err := unmarshalID(fieldType, fieldVal, resourceID, opts); err != nil {
 			return err
 		}
+		if getJsonapiFieldType(fieldType) == "lid" {
+			if lid, ok := data["lid"].(string); ok && fieldVal.Kind() == reflect.String {
+				fieldVal.SetString(lid)
+			}
+			continue
+		}
 
 		if attributesValid {
-			unmarshalAttributes(fieldType, fieldVal, resourceAttributes)
+			unmarshalAttributes(fieldType, fieldVal, resourceAttributes, opts)
 		}
 		if relationshipsValid {
-			if err := unmarshalRelationships(fieldType, fieldVal, resourceRelationships, included); err != nil {
+			if err := unmarshalRelationships(fieldType, fieldVal, resourceRelationships, graph, opts); err != nil {
 				return err
 			}
 		}
@@ -188,61 +261,132 @@ func unmarshalOne(data map[string]interface{}, model interface{}, included []int
 	return nil
 }
 
-func isIDField(fieldType reflect.StructField, resourceType string) (bool, error) {
-	jsonapitag := fieldType.Tag.Get("jsonapi")
-	if jsonapitag != "" {
-		parts := strings.Split(jsonapitag, ",")
-		if len(parts) > 1 && parts[0] == "primary" {
-			if parts[1] != resourceType {
-				return false, fmt.Errorf("resource type does not match model type, expect %s, got %s", parts[1], resourceType)
-			}
-			return true, nil
-		}
+func isFieldProvided(fieldType reflect.StructField, resourceID interface{}, resourceAttributes map[string]interface{}, resourceRelationships map[string]interface{}) bool {
+	switch getJsonapiFieldType(fieldType) {
+	case "primary":
+		return resourceID != nil
+	case "relation":
+		_, ok := resourceRelationships[getAttributeName(fieldType)]
+		return ok
+	default:
+		_, ok := resourceAttributes[getAttributeName(fieldType)]
+		return ok
 	}
-	return false, nil
 }
 
-func unmarshalID(fieldType reflect.StructField, fieldVal reflect.Value, resourceID interface{}, resourceType string) error {
-	isId, err := isIDField(fieldType, resourceType)
+// checkResourceTypeOf compares the resource type of the data with the one of the model, see getResourceType.
+// Models without a primary key are not checked, neither are models that cannot tell their type before they are
+// filled in, i.e. having no type in the primary tag and JSONAPIType returning an empty string.
+func checkResourceTypeOf(modelVal reflect.Value, modelType reflect.Type, resourceType interface{}) error {
+	expected, err := getResourceType(modelVal, modelType)
+	if errors.Is(err, errNoPrimaryKey) || errors.Is(err, errNoResourceType) {
+		return nil
+	}
 	if err != nil {
 		return err
 	}
+	if resourceType != expected {
+		return fmt.Errorf("resource type does not match model type, expect %s, got %v", expected, resourceType)
+	}
+	return nil
+}
+
+func isIDField(fieldType reflect.StructField) bool {
+	return getJsonapiFieldType(fieldType) == "primary"
+}
 
-	if !isId || resourceID == nil { //The field is not id, or ID value is not provided
+func unmarshalID(fieldType reflect.StructField, fieldVal reflect.Value, resourceID interface{}, opts *options) error {
+	if !isIDField(fieldType) || resourceID == nil { //The field is not id, or ID value is not provided
 		return nil
 	}
 
-	stringMarshallerType := reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	return setIDValue(fieldVal, resourceID, opts)
+}
+
+func setIDValue(fieldVal reflect.Value, resourceID interface{}, opts *options) error {
+	if isHandled, err := decodeWithCodec(fieldVal, resourceID, opts); isHandled {
+		return err
+	}
+
+	stringUnmarshallerType := reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
 
 	switch {
-	case fieldVal.Type().Implements(stringMarshallerType):
+	case fieldVal.Kind() == reflect.Pointer:
 		v := reflect.New(fieldVal.Type().Elem())
-		v.MethodByName("UnmarshalText").
-			Call([]reflect.Value{reflect.ValueOf([]byte(resourceID.(string)))})
+		if err := setIDValue(v.Elem(), resourceID, opts); err != nil {
+			return err
+		}
 		fieldVal.Set(v)
-	case reflect.PointerTo(fieldVal.Type()).Implements(stringMarshallerType):
+	case reflect.PointerTo(fieldVal.Type()).Implements(stringUnmarshallerType):
+		id, ok := resourceID.(string)
+		if !ok {
+			return fmt.Errorf("ID value must be a string to unmarshal into %s", fieldVal.Type())
+		}
 		v := reflect.New(fieldVal.Type())
-		v.MethodByName("UnmarshalText").
-			Call([]reflect.Value{reflect.ValueOf([]byte(resourceID.(string)))})
+		if err := v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(id)); err != nil {
+			return err
+		}
 		fieldVal.Set(v.Elem())
 	default:
 		switch fieldVal.Kind() {
 		case reflect.String:
+			if id, ok := resourceID.(json.Number); ok {
+				fieldVal.SetString(id.String())
+				return nil
+			}
 			fieldVal.SetString(resourceID.(string))
 		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			fieldVal.SetInt(int64(resourceID.(float64)))
+			switch id := resourceID.(type) {
+			case string:
+				v, err := strconv.ParseInt(id, 10, fieldVal.Type().Bits())
+				if err != nil {
+					return err
+				}
+				fieldVal.SetInt(v)
+			default:
+				v, err := parseNumber(fieldVal.Kind(), resourceID)
+				if err != nil {
+					return err
+				}
+				fieldVal.SetInt(v.Int())
+			}
 		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			fieldVal.SetUint(uint64(resourceID.(float64)))
+			switch id := resourceID.(type) {
+			case string:
+				v, err := strconv.ParseUint(id, 10, fieldVal.Type().Bits())
+				if err != nil {
+					return err
+				}
+				fieldVal.SetUint(v)
+			default:
+				v, err := parseNumber(fieldVal.Kind(), resourceID)
+				if err != nil {
+					return err
+				}
+				fieldVal.SetUint(v.Uint())
+			}
+		case reflect.Array:
+			id, ok := resourceID.(string)
+			if !isByteArray(fieldVal.Type()) || !ok {
+				return errors.New("ID field must be a string, number, byte array or implement encoding.TextUnmarshaler")
+			}
+			b, err := hex.DecodeString(id)
+			if err != nil {
+				return fmt.Errorf("invalid hex id %q: %w", id, err)
+			}
+			if len(b) != fieldVal.Len() {
+				return fmt.Errorf("invalid hex id %q, expected %d bytes, got %d", id, fieldVal.Len(), len(b))
+			}
+			reflect.Copy(fieldVal, reflect.ValueOf(b))
 		default:
-			return errors.New("ID field must be a string, number or implement encoding.TextUnmarshaler")
+			return errors.New("ID field must be a string, number, byte array or implement encoding.TextUnmarshaler")
 		}
-
 	}
 
 	return nil
 }
 
-func unmarshalAttributes(fieldType reflect.StructField, fieldVal reflect.Value, resourceAttributes map[string]interface{}) {
+func unmarshalAttributes(fieldType reflect.StructField, fieldVal reflect.Value, resourceAttributes map[string]interface{}, opts *options) {
 	attributeName := getAttributeName(fieldType)
 
 	defer func() {
@@ -256,17 +400,23 @@ func unmarshalAttributes(fieldType reflect.StructField, fieldVal reflect.Value,
 		}
 	}()
 	if attribute, ok := resourceAttributes[attributeName]; ok {
-		unmarshalSingleAttribute(fieldVal, attribute)
+		unmarshalSingleAttribute(fieldVal, attribute, opts.forField(fieldType))
 	}
 }
 
-func unmarshalSingleAttribute(fieldVal reflect.Value, attribute interface{}) {
+func unmarshalSingleAttribute(fieldVal reflect.Value, attribute interface{}, opts *options) {
+	if isHandled, err := decodeWithCodec(fieldVal, attribute, opts); isHandled {
+		if err != nil {
+			panic(err)
+		}
+		return
+	}
 
 	switch fieldVal.Kind() {
 	case reflect.Struct:
-		unmarshalSingleStruct(fieldVal, attribute)
+		unmarshalSingleStruct(fieldVal, attribute, opts)
 	case reflect.Pointer:
-		unmarshalSinglePointer(fieldVal, attribute)
+		unmarshalSinglePointer(fieldVal, attribute, opts)
 	case reflect.Slice:
 		dataSlice, ok := attribute.([]interface{})
 		if !ok {
@@ -286,11 +436,14 @@ func unmarshalSingleAttribute(fieldVal reflect.Value, attribute interface{}) {
 		for _, datapoint := range dataSlice {
 			var primitiveVal reflect.Value
 
-			if fieldValueKind == reflect.Ptr {
+			if opts.hasCodec(fieldValueType) {
+				primitiveVal = reflect.New(fieldValueType).Elem()
+				unmarshalSingleAttribute(primitiveVal, datapoint, opts)
+			} else if fieldValueKind == reflect.Ptr {
 				if fieldValueType.Elem().Kind() == reflect.Struct {
 					primitiveVal = reflect.New(fieldValueType.Elem()).Elem()
 					//Operates on struct so that it's addressable
-					unmarshalSingleStruct(primitiveVal, datapoint)
+					unmarshalSingleStruct(primitiveVal, datapoint, opts)
 					//Wrap it back as pointer
 					primitiveVal = primitiveVal.Addr()
 				} else {
@@ -298,7 +451,7 @@ func unmarshalSingleAttribute(fieldVal reflect.Value, attribute interface{}) {
 				}
 			} else if fieldValueKind == reflect.Struct {
 				primitiveVal = reflect.New(fieldValueType).Elem()
-				unmarshalSingleStruct(primitiveVal, datapoint)
+				unmarshalSingleStruct(primitiveVal, datapoint, opts)
 			} else {
 				primitiveVal = castPrimitive(fieldValueKind, fieldValueType, datapoint)
 			}
@@ -328,13 +481,16 @@ func unmarshalSingleAttribute(fieldVal reflect.Value, attribute interface{}) {
 		for key, value := range dataMap {
 			var primitiveVal reflect.Value
 
-			switch fieldValueKind {
-			case reflect.Ptr:
+			switch {
+			case opts.hasCodec(fieldValueType):
+				primitiveVal = reflect.New(fieldValueType).Elem()
+				unmarshalSingleAttribute(primitiveVal, value, opts)
+			case fieldValueKind == reflect.Ptr:
 				primitiveVal = reflect.New(fieldValueType).Elem() //Still has to be unwrapped as .New returns a pointer itself
-				unmarshalSinglePointer(primitiveVal, value)
-			case reflect.Struct:
+				unmarshalSinglePointer(primitiveVal, value, opts)
+			case fieldValueKind == reflect.Struct:
 				primitiveVal = reflect.New(fieldValueType).Elem()
-				unmarshalSingleStruct(primitiveVal, value)
+				unmarshalSingleStruct(primitiveVal, value, opts)
 			default:
 				primitiveVal = castPrimitive(fieldValueKind, fieldValueType, value)
 			}
@@ -351,8 +507,8 @@ func unmarshalSingleAttribute(fieldVal reflect.Value, attribute interface{}) {
 	}
 }
 
-func unmarshalSingleStruct(fieldVal reflect.Value, attribute interface{}) {
-	isHandled, err := unmarshalTime(fieldVal, attribute)
+func unmarshalSingleStruct(fieldVal reflect.Value, attribute interface{}, opts *options) {
+	isHandled, err := unmarshalTime(fieldVal, attribute, opts)
 	if err != nil {
 		panic(err)
 	}
@@ -366,20 +522,28 @@ func unmarshalSingleStruct(fieldVal reflect.Value, attribute interface{}) {
 	if isHandled {
 		return
 	}
+	isHandled, err = unmarshalBigFloat(fieldVal, attribute)
+	if err != nil {
+		panic(err)
+	}
+	if isHandled {
+		return
+	}
 
 	var toFillIn = reflect.New(fieldVal.Type())
 
-	for i := 0; i < toFillIn.Elem().NumField(); i++ {
-		nextFieldType := toFillIn.Elem().Type().Field(i)
-		nextFieldVal := toFillIn.Elem().Field(i)
-		unmarshalAttributes(nextFieldType, nextFieldVal, attribute.(map[string]interface{}))
+	for _, nextFieldType := range resourceFields(fieldVal.Type()) {
+		if isFieldProvided(nextFieldType, nil, attribute.(map[string]interface{}), nil) {
+			nextFieldVal := fieldByIndexAlloc(toFillIn.Elem(), nextFieldType.Index)
+			unmarshalAttributes(nextFieldType, nextFieldVal, attribute.(map[string]interface{}), opts)
+		}
 	}
 
 	fieldVal.Set(toFillIn.Elem())
 }
 
-func unmarshalSinglePointer(fieldVal reflect.Value, attribute interface{}) {
-	isHandled, err := unmarshalTime(fieldVal, attribute)
+func unmarshalSinglePointer(fieldVal reflect.Value, attribute interface{}, opts *options) {
+	isHandled, err := unmarshalTime(fieldVal, attribute, opts)
 	if err != nil {
 		panic(err)
 	}
@@ -393,26 +557,36 @@ func unmarshalSinglePointer(fieldVal reflect.Value, attribute interface{}) {
 	if isHandled {
 		return
 	}
+	isHandled, err = unmarshalBigFloat(fieldVal, attribute)
+	if err != nil {
+		panic(err)
+	}
+	if isHandled {
+		return
+	}
 	toFillIn := reflect.New(fieldVal.Type().Elem())
 
 	if fieldVal.Type().Elem().Kind() == reflect.Struct {
-		for i := 0; i < toFillIn.Elem().NumField(); i++ {
-			//pointer can be to nil which is legit in this scenario
-			//Should leave as zero value in this case
-			if attribute != nil {
-				nextFieldType := toFillIn.Elem().Type().Field(i)
-				nextFieldVal := toFillIn.Elem().Field(i)
-				unmarshalAttributes(nextFieldType, nextFieldVal, attribute.(map[string]interface{}))
-				fieldVal.Set(toFillIn)
+		//pointer can be to nil which is legit in this scenario
+		//Should leave as zero value in this case
+		if attribute != nil {
+			for _, nextFieldType := range resourceFields(fieldVal.Type().Elem()) {
+				if isFieldProvided(nextFieldType, nil, attribute.(map[string]interface{}), nil) {
+					nextFieldVal := fieldByIndexAlloc(toFillIn.Elem(), nextFieldType.Index)
+					unmarshalAttributes(nextFieldType, nextFieldVal, attribute.(map[string]interface{}), opts)
+				}
 			}
+			fieldVal.Set(toFillIn)
 		}
+	} else if attribute == nil {
+		fieldVal.Set(reflect.Zero(fieldVal.Type()))
 	} else {
-		toFillIn.Elem().Set(castPrimitive(fieldVal.Type().Elem().Kind(), fieldVal.Type(), attribute))
+		toFillIn.Elem().Set(castPrimitive(fieldVal.Type().Elem().Kind(), fieldVal.Type().Elem(), attribute))
 		fieldVal.Set(toFillIn)
 	}
 }
 
-func unmarshalTime(fieldVal reflect.Value, attribute interface{}) (bool, error) {
+func unmarshalTime(fieldVal reflect.Value, attribute interface{}, opts *options) (bool, error) {
 
 	switch fieldVal.Type().Kind() {
 	case reflect.Pointer:
@@ -422,7 +596,7 @@ func unmarshalTime(fieldVal reflect.Value, attribute interface{}) (bool, error)
 				return true, nil
 			}
 
-			concreteValue, err := time.Parse(time.RFC3339, attribute.(string))
+			concreteValue, err := parseTime(attribute, opts)
 			if err != nil {
 				return false, err
 			}
@@ -432,7 +606,7 @@ func unmarshalTime(fieldVal reflect.Value, attribute interface{}) (bool, error)
 		}
 	case reflect.Struct:
 		if fieldVal.Type() == reflect.TypeOf(time.Time{}) {
-			concreteValue, err := time.Parse(time.RFC3339, attribute.(string))
+			concreteValue, err := parseTime(attribute, opts)
 			if err != nil {
 				return false, err
 			}
@@ -444,6 +618,31 @@ func unmarshalTime(fieldVal reflect.Value, attribute interface{}) (bool, error)
 	return false, nil
 }
 
+func parseTime(attribute interface{}, opts *options) (time.Time, error) {
+	switch opts.timeFormat {
+	case timeFormatUnix, timeFormatUnixMilli:
+		text, ok := numberText(attribute)
+		if !ok {
+			return time.Time{}, fmt.Errorf("expected a number for %s time, got %T", opts.timeFormat, attribute)
+		}
+		number, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			//Fractional timestamps are truncated
+			f, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return time.Time{}, err
+			}
+			number = int64(f)
+		}
+		if opts.timeFormat == timeFormatUnixMilli {
+			return time.UnixMilli(number), nil
+		}
+		return time.Unix(number, 0), nil
+	default:
+		return time.Parse(opts.timeFormat, attribute.(string))
+	}
+}
+
 func unmarshalUnmarshaler(fieldVal reflect.Value, attribute interface{}) (bool, error) {
 	switch fieldVal.Type().Kind() {
 	case reflect.Ptr:
@@ -453,8 +652,9 @@ func unmarshalUnmarshaler(fieldVal reflect.Value, attribute interface{}) (bool,
 			if err != nil {
 				return false, err
 			}
-			v.MethodByName("UnmarshalJSON").
-				Call([]reflect.Value{reflect.ValueOf(recoded)})
+			if err := v.Interface().(json.Unmarshaler).UnmarshalJSON(recoded); err != nil {
+				return true, err
+			}
 			fieldVal.Set(v)
 			return true, nil
 		}
@@ -465,8 +665,9 @@ func unmarshalUnmarshaler(fieldVal reflect.Value, attribute interface{}) (bool,
 			if err != nil {
 				return false, err
 			}
-			v.MethodByName("UnmarshalJSON").
-				Call([]reflect.Value{reflect.ValueOf(recoded)})
+			if err := v.Interface().(json.Unmarshaler).UnmarshalJSON(recoded); err != nil {
+				return true, err
+			}
 			fieldVal.Set(v.Elem())
 			return true, nil
 		}
@@ -479,52 +680,50 @@ func castPrimitive(kind reflect.Kind, fieldType reflect.Type, attribute interfac
 	stringUnmarshallerType := reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
 
 	switch {
+	case fieldType == jsonNumberType:
+		text, ok := numberText(attribute)
+		if !ok {
+			panic(fmt.Errorf("expected a number for json.Number, got %T", attribute))
+		}
+		return reflect.ValueOf(json.Number(text))
 	case fieldType.Implements(stringUnmarshallerType):
 		v := reflect.New(fieldType.Elem())
-		v.MethodByName("UnmarshalText").
-			Call([]reflect.Value{reflect.ValueOf([]byte(attribute.(string)))})
+		callUnmarshalText(v, attribute)
 		return v.Elem()
 	case reflect.PointerTo(fieldType).Implements(stringUnmarshallerType):
 		v := reflect.New(fieldType)
-		v.MethodByName("UnmarshalText").
-			Call([]reflect.Value{reflect.ValueOf([]byte(attribute.(string)))})
+		callUnmarshalText(v, attribute)
 		return v.Elem()
 	default:
-		switch kind {
-		case reflect.Bool:
+		switch {
+		case kind == reflect.Bool:
 			return reflect.ValueOf(attribute.(bool))
-		case reflect.Int8:
-			return reflect.ValueOf(int8(attribute.(float64)))
-		case reflect.Int:
-			return reflect.ValueOf(int(attribute.(float64)))
-		case reflect.Int16:
-			return reflect.ValueOf(int16(attribute.(float64)))
-		case reflect.Int32:
-			return reflect.ValueOf(int32(attribute.(float64)))
-		case reflect.Int64:
-			return reflect.ValueOf(int64(attribute.(float64)))
-		case reflect.Uint8:
-			return reflect.ValueOf(uint8(attribute.(float64)))
-		case reflect.Uint:
-			return reflect.ValueOf(uint(attribute.(float64)))
-		case reflect.Uint16:
-			return reflect.ValueOf(uint16(attribute.(float64)))
-		case reflect.Uint32:
-			return reflect.ValueOf(uint32(attribute.(float64)))
-		case reflect.Uint64:
-			return reflect.ValueOf(uint64(attribute.(float64)))
-		case reflect.Float32:
-			return reflect.ValueOf(float32(attribute.(float64)))
-		case reflect.Float64:
-			return reflect.ValueOf(attribute.(float64))
-		case reflect.String:
+		case isNumberKind(kind):
+			v, err := parseNumber(kind, attribute)
+			if err != nil {
+				panic(err)
+			}
+			return v
+		case kind == reflect.String:
 			return reflect.ValueOf(attribute.(string))
+		case attribute == nil:
+			return reflect.Zero(fieldType)
 		default:
-			return reflect.ValueOf(attribute)
+			return reflect.ValueOf(normalizeNumbers(attribute))
 		}
 	}
 }
 
+// callUnmarshalText feeds the text unmarshaler with a string attribute, or with the literal of a number.
+// Errors of the text unmarshaler are not reported on attributes, the field keeps whatever the unmarshaler left in it.
+func callUnmarshalText(v reflect.Value, attribute interface{}) {
+	text, ok := numberText(attribute)
+	if !ok {
+		text = attribute.(string)
+	}
+	_ = v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(text))
+}
+
 func castPrimitivePointer(concreteKind reflect.Kind, concreteType reflect.Type, attribute interface{}) reflect.Value {
 	stringUnmarshallerType := reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
 	switch {
@@ -538,88 +737,67 @@ func castPrimitivePointer(concreteKind reflect.Kind, concreteType reflect.Type,
 		v.MethodByName("UnmarshalText").
 			Call([]reflect.Value{reflect.ValueOf([]byte(attribute.(string)))})
 		return v
+	case isNumberKind(concreteKind) || concreteType.Elem() == jsonNumberType:
+		cVal := castPrimitive(concreteKind, concreteType.Elem(), attribute)
+		v := reflect.New(cVal.Type())
+		v.Elem().Set(cVal)
+		return v
 	default:
 		switch concreteKind {
 		case reflect.Bool:
 			cVal := attribute.(bool)
 			return reflect.ValueOf(&cVal)
-		case reflect.Int8:
-			cVal := int8(attribute.(float64))
-			return reflect.ValueOf(&cVal)
-		case reflect.Int:
-			cVal := int(attribute.(float64))
-			return reflect.ValueOf(&cVal)
-		case reflect.Int16:
-			cVal := int16(attribute.(float64))
-			return reflect.ValueOf(&cVal)
-		case reflect.Int32:
-			cVal := int32(attribute.(float64))
-			return reflect.ValueOf(&cVal)
-		case reflect.Int64:
-			cVal := int64(attribute.(float64))
-			return reflect.ValueOf(&cVal)
-		case reflect.Uint8:
-			cVal := uint8(attribute.(float64))
-			return reflect.ValueOf(&cVal)
-		case reflect.Uint:
-			cVal := uint(attribute.(float64))
-			return reflect.ValueOf(&cVal)
-		case reflect.Uint16:
-			cVal := uint16(attribute.(float64))
-			return reflect.ValueOf(&cVal)
-		case reflect.Uint32:
-			cVal := uint32(attribute.(float64))
-			return reflect.ValueOf(&cVal)
-		case reflect.Uint64:
-			cVal := uint64(attribute.(float64))
-			return reflect.ValueOf(&cVal)
-		case reflect.Float32:
-			cVal := float32(attribute.(float64))
-			return reflect.ValueOf(&cVal)
-		case reflect.Float64:
-			cVal := attribute.(float64)
-			return reflect.ValueOf(&cVal)
 		case reflect.String:
 			cVal := attribute.(string)
 			return reflect.ValueOf(&cVal)
 		default:
+			attribute = normalizeNumbers(attribute)
 			return reflect.ValueOf(&attribute)
 		}
 	}
 }
 
-func unmarshalRelationships(fieldType reflect.StructField, fieldVal reflect.Value, resourceRelationships map[string]interface{}, included []interface{}) error {
+func unmarshalRelationships(fieldType reflect.StructField, fieldVal reflect.Value, resourceRelationships map[string]interface{}, graph *unmarshalGraph, opts *options) error {
 	relationshipName := getAttributeName(fieldType)
 	if relationship, ok := resourceRelationships[relationshipName]; ok {
-		data, ok := relationship.(map[string]interface{})["data"] //normalised data of relationship containing type and id / list of ids
+		relationshipData, ok := relationship.(map[string]interface{})
 		if !ok {
 			return errors.New("invalid relationship data structure")
 		}
-		err := unmarshalSingleRelationship(fieldVal, data, included)
-		if err != nil {
-			return err
-		}
+		return unmarshalRelationship(fieldType, fieldVal, relationshipData, graph, opts)
 	}
 
 	return nil
 }
 
-func unmarshalSingleRelationship(fieldVal reflect.Value, relationship interface{}, included []interface{}) error {
+// unmarshalSingleRelationship fills in the relation field from relationship data. Resource type, when set, is
+// accepted in place of the type of the related struct.
+func unmarshalSingleRelationship(fieldVal reflect.Value, relationship interface{}, resourceType string, graph *unmarshalGraph, opts *options) error {
 	//relationship here should be extended with attributes and references from corresponding included if available
 
 	switch fieldVal.Kind() {
-	case reflect.Struct:
-		var toFillIn = reflect.New(fieldVal.Type())
+	case reflect.Interface:
+		if relationship == nil {
+			fieldVal.Set(reflect.Zero(fieldVal.Type()))
+			return nil
+		}
 
-		if err := unmarshalOne(resolveRelationshipData(relationship.(map[string]interface{}), included), toFillIn.Interface(), included); err != nil {
+		instance, err := unmarshalPolymorphic(relationship.(map[string]interface{}), fieldVal.Type(), graph, opts)
+		if err != nil {
 			return err
 		}
 
-		fieldVal.Set(toFillIn.Elem())
+		fieldVal.Set(instance)
 		return nil
-	case reflect.Pointer:
-		toFillIn := reflect.New(fieldVal.Type().Elem())
+	case reflect.Struct:
+		instance, err := unmarshalInstance(relationship.(map[string]interface{}), fieldVal.Type(), resourceType, graph, opts)
+		if err != nil {
+			return err
+		}
 
+		fieldVal.Set(instance.Elem())
+		return nil
+	case reflect.Pointer:
 		if relationship == nil {
 			//empty relationship is only legit for pointers
 			//init pointer to nil
@@ -627,11 +805,12 @@ func unmarshalSingleRelationship(fieldVal reflect.Value, relationship interface{
 			return nil
 		}
 
-		if err := unmarshalOne(resolveRelationshipData(relationship.(map[string]interface{}), included), toFillIn.Interface(), included); err != nil {
+		instance, err := unmarshalInstance(relationship.(map[string]interface{}), fieldVal.Type().Elem(), resourceType, graph, opts)
+		if err != nil {
 			return err
 		}
 
-		fieldVal.Set(toFillIn)
+		fieldVal.Set(instance)
 
 		return nil
 	case reflect.Slice:
@@ -647,22 +826,31 @@ func unmarshalSingleRelationship(fieldVal reflect.Value, relationship interface{
 		slicePtr := reflect.ValueOf(reflectionValue.Interface())
 		sliceValuePtr := slicePtr.Elem()
 
-		for _, datapoint := range dataSlice {
-			toFillIn := reflect.New(fieldVal.Type().Elem())
+		elemType := fieldVal.Type().Elem()
+		if elemType.Kind() == reflect.Ptr {
+			elemType = elemType.Elem()
+		}
 
-			if fieldVal.Type().Elem().Kind() == reflect.Ptr {
-				toFillIn = reflect.New(fieldVal.Type().Elem().Elem())
+		for _, datapoint := range dataSlice {
+			if elemType.Kind() == reflect.Interface {
+				instance, err := unmarshalPolymorphic(datapoint.(map[string]interface{}), elemType, graph, opts)
+				if err != nil {
+					return err
+				}
+				sliceValuePtr.Set(reflect.Append(sliceValuePtr, instance))
+				continue
 			}
 
-			if err := unmarshalOne(resolveRelationshipData(datapoint.(map[string]interface{}), included), toFillIn.Interface(), included); err != nil {
+			instance, err := unmarshalInstance(datapoint.(map[string]interface{}), elemType, resourceType, graph, opts)
+			if err != nil {
 				return err
 			}
 
 			var toAppend reflect.Value
 			if fieldVal.Type().Elem().Kind() == reflect.Struct {
-				toAppend = toFillIn.Elem()
+				toAppend = instance.Elem()
 			} else {
-				toAppend = toFillIn
+				toAppend = instance
 			}
 			sliceValuePtr.Set(reflect.Append(sliceValuePtr, toAppend))
 		}
@@ -674,18 +862,116 @@ func unmarshalSingleRelationship(fieldVal reflect.Value, relationship interface{
 	}
 }
 
-func resolveRelationshipData(referenceData map[string]interface{}, included []interface{}) map[string]interface{} {
-	referencedType := referenceData["type"]
-	referencedId := referenceData["id"]
+// unmarshalInstance returns a pointer to the model of the resource. Every resource is unmarshaled once per document
+// and Go type, further references to it share the instance. Value typed relationships receive a copy of it.
+// Resource type, when set, is accepted in place of the type of the model primary tag.
+func unmarshalInstance(data map[string]interface{}, modelType reflect.Type, resourceType string, graph *unmarshalGraph, opts *options) (reflect.Value, error) {
+	resolved := resolveRelationshipData(data, graph)
+	if instance, ok := graph.instance(resolved, modelType); ok {
+		return instance, nil
+	}
+
+	instance := reflect.New(modelType)
+	if err := unmarshalOneAs(resolved, instance.Interface(), resourceType, graph, opts); err != nil {
+		return reflect.Value{}, err
+	}
+	return instance, nil
+}
+
+// resourceKey identifies a resource object or a resource identifier by type and id, or by type and lid if the
+// resource has no id yet.
+type resourceKey struct {
+	resourceType string
+	id           string
+	lid          string
+}
+
+func resourceKeyOf(data map[string]interface{}) (resourceKey, bool) {
+	resourceType, ok := data["type"].(string)
+	if !ok {
+		return resourceKey{}, false
+	}
+
+	key := resourceKey{resourceType: resourceType}
+	switch id := data["id"].(type) {
+	case string:
+		key.id = id
+	case json.Number:
+		key.id = id.String()
+	}
+	if key.id == "" {
+		key.lid, _ = data["lid"].(string)
+		if key.lid == "" {
+			return resourceKey{}, false
+		}
+	}
+	return key, true
+}
+
+type instanceKey struct {
+	resource  resourceKey
+	modelType reflect.Type
+}
 
-	for _, includedResource := range included {
-		includedResourceData, ok := includedResource.(map[string]interface{})
+// unmarshalGraph holds the state shared by all resources of a single document: resource objects by type and id,
+// and model instances created for them.
+type unmarshalGraph struct {
+	resources map[resourceKey]map[string]interface{}
+	instances map[instanceKey]reflect.Value
+}
+
+// newUnmarshalGraph indexes primary and included resources, so that relationships pointing to primary resources
+// resolve to them as well.
+func newUnmarshalGraph(data interface{}, included interface{}) *unmarshalGraph {
+	list, _ := included.([]interface{})
+	if primary, ok := data.([]interface{}); ok {
+		list = append(slices.Clone(primary), list...)
+	} else if primary, ok := data.(map[string]interface{}); ok {
+		list = append([]interface{}{primary}, list...)
+	}
+
+	graph := &unmarshalGraph{
+		resources: make(map[resourceKey]map[string]interface{}, len(list)),
+		instances: map[instanceKey]reflect.Value{},
+	}
+	for _, resource := range list {
+		resourceData, ok := resource.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		key, ok := resourceKeyOf(resourceData)
 		if !ok {
 			continue
 		}
+		//First occurrence wins for duplicated resources
+		if _, exists := graph.resources[key]; !exists {
+			graph.resources[key] = resourceData
+		}
+	}
+	return graph
+}
+
+func (g *unmarshalGraph) instance(data map[string]interface{}, modelType reflect.Type) (reflect.Value, bool) {
+	key, ok := resourceKeyOf(data)
+	if !ok {
+		return reflect.Value{}, false
+	}
+	instance, ok := g.instances[instanceKey{resource: key, modelType: modelType}]
+	return instance, ok
+}
+
+func (g *unmarshalGraph) register(data map[string]interface{}, instance reflect.Value) {
+	key, ok := resourceKeyOf(data)
+	if !ok {
+		return
+	}
+	g.instances[instanceKey{resource: key, modelType: instance.Type().Elem()}] = instance
+}
 
-		if includedResourceData["type"] == referencedType && includedResourceData["id"] == referencedId {
-			return includedResourceData
+func resolveRelationshipData(referenceData map[string]interface{}, graph *unmarshalGraph) map[string]interface{} {
+	if key, ok := resourceKeyOf(referenceData); ok {
+		if resourceData, ok := graph.resources[key]; ok {
+			return resourceData
 		}
 	}
 
@@ -2,6 +2,7 @@ package jsonapi
 
 import (
 	"encoding/json"
+	"errors"
 	"maps"
 	"reflect"
 	"strings"
@@ -330,6 +331,96 @@ func TestUnmarshalOne(t *testing.T) {
 			}
 		}
 	})
+
+	t.Run("should not fail on text unmarshaler errors of attributes", func(t *testing.T) {
+		type SUT struct {
+			ID   string             `jsonapi:"primary,tests"`
+			Hash StringSerializable `jsonapi:"attr,hash"`
+			Name string             `jsonapi:"attr,name"`
+		}
+
+		out := SUT{}
+		err := Unmarshal([]byte(`{"data":{"type":"tests","id":"1","attributes":{"hash":"zz","name":"n"}}}`), &out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out.Hash != (StringSerializable{}) || out.Name != "n" {
+			t.Fatal("unexpected model", out)
+		}
+	})
+}
+
+func TestUnmarshalNullData(t *testing.T) {
+	type Test struct {
+		ID  string `jsonapi:"primary,test"`
+		Str string
+	}
+
+	t.Run("should zero struct models and report missing resource", func(t *testing.T) {
+		out := Test{ID: "1", Str: "stale"}
+		err := Unmarshal([]byte(`{"data":null}`), &out)
+		if !errors.Is(err, ErrNoResource) {
+			t.Fatal("expected missing resource error", err)
+		}
+		if out != (Test{}) {
+			t.Fatal("expected zeroed model", out)
+		}
+	})
+
+	t.Run("should set pointer models to nil", func(t *testing.T) {
+		out := &Test{ID: "1"}
+		if err := Unmarshal([]byte(`{"data":null}`), &out); err != nil {
+			t.Fatal(err)
+		}
+		if out != nil {
+			t.Fatal("expected nil model", out)
+		}
+
+		if err := Unmarshal([]byte(`{"data":{"type":"test","id":"2"}}`), &out); err != nil {
+			t.Fatal(err)
+		}
+		if out == nil || out.ID != "2" {
+			t.Fatal("expected allocated model", out)
+		}
+	})
+
+	t.Run("should empty collections", func(t *testing.T) {
+		out := []Test{{ID: "1"}}
+		if err := Unmarshal([]byte(`{"data":null}`), &out); err != nil {
+			t.Fatal(err)
+		}
+		if len(out) != 0 {
+			t.Fatal("expected empty collection", out)
+		}
+
+		models, err := UnmarshalManyAsType([]byte(`{"data":null}`), reflect.TypeOf(&Test{}))
+		if err != nil || len(models) != 0 {
+			t.Fatal("expected empty collection", models, err)
+		}
+	})
+
+	t.Run("should empty pre-filled collections on empty data", func(t *testing.T) {
+		out := []Test{{ID: "1"}}
+		if err := Unmarshal([]byte(`{"data":[]}`), &out); err != nil {
+			t.Fatal(err)
+		}
+		if out == nil || len(out) != 0 {
+			t.Fatal("expected empty collection", out)
+		}
+	})
+
+	t.Run("should return nil from typed unmarshal", func(t *testing.T) {
+		model, err := UnmarshalOneAsType([]byte(`{"data":null}`), reflect.TypeOf(&Test{}))
+		if err != nil || model != nil {
+			t.Fatal("expected nil model", model, err)
+		}
+	})
+
+	t.Run("should still reject documents without data", func(t *testing.T) {
+		if err := Unmarshal([]byte(`{"meta":{}}`), &Test{}); err == nil || errors.Is(err, ErrNoResource) {
+			t.Fatal("expected invalid data structure error", err)
+		}
+	})
 }
 
 func TestUnmarshalMany(t *testing.T) {
@@ -479,6 +570,47 @@ func TestUnmarshalID(t *testing.T) {
 		}
 	})
 
+	t.Run("should unmarshal pointer ids with pointer receiver text unmarshalers", func(t *testing.T) {
+		type SUT struct {
+			ID *PointerTextID `jsonapi:"primary,tests"`
+		}
+
+		check := SUT{}
+		if err := Unmarshal([]byte(`{"data": {"type": "tests", "id": "abc"}}`), &check); err != nil {
+			t.Fatal(err)
+		}
+
+		if check.ID == nil || check.ID.value != "abc" {
+			t.Fatal("unexpected id", check.ID)
+		}
+	})
+
+	t.Run("should unmarshal byte array ids from hex", func(t *testing.T) {
+		type SUT struct {
+			UUID [16]byte `jsonapi:"primary,tests"`
+		}
+
+		input := SUT{UUID: [16]byte{0x01, 0x02, 0xab, 15: 0xff}}
+		raw, err := Marshal(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		check := SUT{}
+		if err := Unmarshal(raw, &check); err != nil {
+			t.Fatal(err)
+		}
+		if check != input {
+			t.Fatal("unexpected id", check.UUID)
+		}
+
+		for _, id := range []string{"0102", "zz02ab000000000000000000000000ff"} {
+			if err := Unmarshal([]byte(`{"data": {"type": "tests", "id": "`+id+`"}}`), &SUT{}); err == nil {
+				t.Errorf("expected error for id %s", id)
+			}
+		}
+	})
+
 	t.Run("should omit id if it is empty", func(t *testing.T) {
 		type SUT struct {
 			ID  string `jsonapi:"primary,tests"`
@@ -497,6 +629,56 @@ func TestUnmarshalID(t *testing.T) {
 			t.Fatal("unexpected id")
 		}
 	})
+
+	t.Run("should unmarshal into the tagged primary field regardless of its name", func(t *testing.T) {
+		type Base struct {
+			UUID StringSerializable `jsonapi:"primary,bases"`
+		}
+		type SUT struct {
+			*Base
+			Key     string  `jsonapi:"attr,key"`
+			Counter int64   `jsonapi:"attr,counter"`
+			Ref     *string `jsonapi:"attr,ref"`
+		}
+		type Numeric struct {
+			Key uint32 `jsonapi:"primary,numerics"`
+		}
+		type Pointer struct {
+			Ref *string `jsonapi:"primary,pointers"`
+		}
+
+		out := SUT{}
+		if err := Unmarshal([]byte(`{"data": {"type": "bases", "id": "0a0b0c0d", "attributes": {"key": "attr"}}}`), &out); err != nil {
+			t.Fatal(err)
+		}
+		if out.Base == nil || out.UUID != (StringSerializable{0xa, 0xb, 0xc, 0xd}) || out.Key != "attr" {
+			t.Errorf("unexpected output %+v", out)
+		}
+
+		numeric := Numeric{}
+		if err := Unmarshal([]byte(`{"data": {"type": "numerics", "id": "4294967295"}}`), &numeric); err != nil {
+			t.Fatal(err)
+		}
+		if numeric.Key != 4294967295 {
+			t.Errorf("unexpected numeric id %d", numeric.Key)
+		}
+
+		if err := Unmarshal([]byte(`{"data": {"type": "numerics", "id": "4294967296"}}`), &numeric); err == nil {
+			t.Errorf("expected out of range id to fail")
+		}
+
+		pointer := Pointer{}
+		if err := Unmarshal([]byte(`{"data": {"type": "pointers", "id": "ref"}}`), &pointer); err != nil {
+			t.Fatal(err)
+		}
+		if pointer.Ref == nil || *pointer.Ref != "ref" {
+			t.Errorf("unexpected pointer id %v", pointer.Ref)
+		}
+
+		if err := Unmarshal([]byte(`{"data": {"type": "bases", "id": "zz"}}`), &out); err == nil {
+			t.Errorf("expected UnmarshalText error to be returned")
+		}
+	})
 }
 
 func TestUnmarshalAsType(t *testing.T) {
@@ -598,6 +780,67 @@ func TestUnmarshalTime(t *testing.T) {
 			t.Errorf("expected %+v, got %+v", input, out)
 		}
 	})
+
+	t.Run("should unmarshal time in tagged formats and per call default", func(t *testing.T) {
+		type Nested struct {
+			At     time.Time `json:"at"`
+			AtUnix time.Time `jsonapi:"attr,atUnix,unix"`
+		}
+
+		type SUT struct {
+			ID        string      `jsonapi:"primary,tests"`
+			Default   time.Time   `jsonapi:"attr,default"`
+			Unix      time.Time   `jsonapi:"attr,unix,unix"`
+			UnixMilli *time.Time  `jsonapi:"attr,unixMilli,unixmilli"`
+			Nano      time.Time   `jsonapi:"attr,nano,rfc3339nano"`
+			Date      time.Time   `jsonapi:"attr,date,date"`
+			List      []time.Time `jsonapi:"attr,list,unixmilli"`
+			Nested    Nested      `jsonapi:"attr,nested"`
+		}
+
+		moment := time.Date(2018, 1, 2, 3, 4, 5, 123456789, time.UTC)
+		input := SUT{
+			ID:        "1",
+			Default:   moment,
+			Unix:      moment,
+			UnixMilli: &moment,
+			Nano:      moment,
+			Date:      moment,
+			List:      []time.Time{moment},
+			Nested:    Nested{At: moment, AtUnix: moment},
+		}
+
+		raw, err := Marshal(input, WithTimeFormat("rfc3339nano"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out := SUT{}
+		if err := Unmarshal(raw, &out, WithTimeFormat(time.RFC3339Nano)); err != nil {
+			t.Fatal(err)
+		}
+
+		checks := map[string][2]time.Time{
+			"default":    {out.Default, moment},
+			"unix":       {out.Unix, moment.Truncate(time.Second)},
+			"unixMilli":  {*out.UnixMilli, moment.Truncate(time.Millisecond)},
+			"nano":       {out.Nano, moment},
+			"date":       {out.Date, time.Date(2018, 1, 2, 0, 0, 0, 0, time.UTC)},
+			"list":       {out.List[0], moment.Truncate(time.Millisecond)},
+			"nested":     {out.Nested.At, moment},
+			"nestedUnix": {out.Nested.AtUnix, moment.Truncate(time.Second)},
+		}
+
+		for name, check := range checks {
+			if !check[0].Equal(check[1]) {
+				t.Errorf("expected %s to be %v, got %v", name, check[1], check[0])
+			}
+		}
+
+		if err := Unmarshal([]byte(`{"data": {"type": "tests", "id": "1", "attributes": {"unix": "2018-01-02T03:04:05Z"}}}`), &out); err == nil {
+			t.Errorf("expected error for string value of unix time")
+		}
+	})
 }
 
 func TestUnmarshalMap(t *testing.T) {
@@ -968,6 +1211,85 @@ func TestUnmarshalWithIncluded(t *testing.T) {
 	})
 }
 
+func TestUnmarshalSharedInstances(t *testing.T) {
+
+	type Person struct {
+		ID   string `jsonapi:"primary,people"`
+		Name string `jsonapi:"attr,name"`
+	}
+
+	type Article struct {
+		ID      string    `jsonapi:"primary,articles"`
+		Author  *Person   `jsonapi:"relation,author"`
+		Editors []*Person `jsonapi:"relation,editors"`
+		Next    *Article  `jsonapi:"relation,next"`
+		Copy    Person    `jsonapi:"relation,copy"`
+	}
+
+	t.Run("should share a single instance of a resource referenced multiple times", func(t *testing.T) {
+		raw := []byte(`{"data":[
+			{"type":"articles","id":"1","relationships":{"author":{"data":{"type":"people","id":"1"}},"editors":{"data":[{"type":"people","id":"1"}]}}},
+			{"type":"articles","id":"2","relationships":{"author":{"data":{"type":"people","id":"1"}},"copy":{"data":{"type":"people","id":"1"}}}}
+		],"included":[{"type":"people","id":"1","attributes":{"name":"a"}}]}`)
+
+		out := make([]*Article, 0)
+		if err := Unmarshal(raw, &out); err != nil {
+			t.Fatal(err)
+		}
+
+		if out[0].Author == nil || out[0].Author.Name != "a" {
+			t.Fatal("unexpected author", out[0].Author)
+		}
+		if out[0].Author != out[1].Author || out[0].Author != out[0].Editors[0] {
+			t.Fatal("expected references to share the instance")
+		}
+		if out[1].Copy != *out[0].Author {
+			t.Fatal("expected value relationship to hold a copy", out[1].Copy)
+		}
+	})
+
+	t.Run("should resolve relationships pointing to primary resources", func(t *testing.T) {
+		raw := []byte(`{"data":[
+			{"type":"articles","id":"1","relationships":{"next":{"data":{"type":"articles","id":"2"}}}},
+			{"type":"articles","id":"2","relationships":{"author":{"data":{"type":"people","id":"1"}}}}
+		]}`)
+
+		out, err := UnmarshalManyAs[Article](raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if out[0].Next != out[1] {
+			t.Fatal("expected relationship to point at the primary resource instance")
+		}
+		if out[0].Next.Author == nil || out[0].Next.Author.ID != "1" {
+			t.Fatal("expected primary resource to be fully unmarshaled", out[0].Next)
+		}
+	})
+
+	t.Run("should terminate on cyclic graphs", func(t *testing.T) {
+		a := &CircularA{ID: "1", Val: "a"}
+		a.B = &CircularB{ID: "2", Val: "b", A: a}
+
+		raw, err := Marshal(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out := &CircularA{}
+		if err := Unmarshal(raw, out); err != nil {
+			t.Fatal(err)
+		}
+
+		if out.B == nil || out.B.Val != "b" {
+			t.Fatal("unexpected related resource", out.B)
+		}
+		if out.B.A != out {
+			t.Fatal("expected cycle to point back at the primary instance")
+		}
+	})
+}
+
 func TestUnmarshalStability(t *testing.T) {
 
 	t.Run("should not panic on mismatching type and return it as error value", func(t *testing.T) {
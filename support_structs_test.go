@@ -77,3 +77,16 @@ func (p *PrimitiveSerializable) UnmarshalText(text []byte) error {
 	*p = PrimitiveSerializable(v)
 	return nil
 }
+
+type PointerTextID struct {
+	value string
+}
+
+func (p *PointerTextID) MarshalText() ([]byte, error) {
+	return []byte(p.value), nil
+}
+
+func (p *PointerTextID) UnmarshalText(text []byte) error {
+	p.value = string(text)
+	return nil
+}